@@ -20,8 +20,12 @@ type WerewolfClient struct {
 	uri    *url.URL
 }
 
-func CreateWerewolfClient(serverHost string) (*WerewolfClient, error) {
-	uri, err := url.Parse("http://" + serverHost + game.ClientEndpoint)
+func CreateWerewolfClient(serverHost string, room string) (*WerewolfClient, error) {
+	endpoint := game.ClientEndpoint
+	if room != game.DefaultRoom {
+		endpoint = "/rooms/" + room + game.ClientEndpoint
+	}
+	uri, err := url.Parse("http://" + serverHost + endpoint)
 	if err != nil {
 		log.Fatal(err)
 		return nil, err
@@ -61,7 +65,12 @@ func (w *WerewolfClient) poll() {
 				log.Fatal(err.Error())
 				return
 			}
-			game.SleepAndPlayAudio(clientRes.TurnCode)
+			text := ""
+			if clientRes.Narration != nil {
+				text = clientRes.Narration.Text
+				log.Println(text)
+			}
+			game.Narrate(clientRes.VoicePack, clientRes.TurnCode, clientRes.Cue, text)
 		}
 	}
 }
@@ -0,0 +1,37 @@
+package game
+
+import (
+	"fmt"
+	"log"
+)
+
+const (
+	// explodeInput is the day input telling that the White Wolf exploded.
+	explodeInput = -2
+	// duelInput is the day input telling that the Knight challenged a player.
+	duelInput = -3
+	// voteInput is the day input telling that the votes are in.
+	voteInput = -4
+)
+
+// explosion is the White Wolf taking a player down during the day.
+type explosion struct {
+	wolfId   int
+	targetId int
+}
+
+// announce adds a public announcement to the news of the day, kept until the next day breaks.
+func (c *Controller) announce(msg string) {
+	log.Println(msg)
+	c.announcements = append(c.announcements, msg)
+}
+
+// explode kills the White Wolf and the player they took, ending the day
+// without banishment.
+func (c *Controller) explode() {
+	e := c.explosion
+	c.explosion = nil
+	c.kill(e.wolfId, false)
+	c.kill(e.targetId, false)
+	c.announce(fmt.Sprintf("Player %d is the White Wolf and exploded, taking player %d with them. There is no banishment today.", e.wolfId+1, e.targetId+1))
+}
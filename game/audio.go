@@ -0,0 +1,89 @@
+package game
+
+import (
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// AudioPlayer plays the narration clips, given by their path.
+type AudioPlayer interface {
+	Play(fileName string) error
+}
+
+// DefaultAudioPlayer plays the clips of the local game and of the audio client.
+var DefaultAudioPlayer AudioPlayer = CreateCommandPlayer("afplay")
+
+// audioCommands are the arguments passed to known players, before the clip.
+var audioCommands = map[string][]string{
+	"afplay": {},
+	"mpg123": {"-q"},
+	"ffplay": {"-nodisp", "-autoexit", "-loglevel", "quiet"},
+	"aplay":  {"-q"},
+}
+
+// CommandPlayer plays a clip by running an external command with the path of the clip as last argument.
+type CommandPlayer struct {
+	Name string
+	Args []string
+}
+
+// CreateCommandPlayer creates a player for a command line such as "mpg123 -q".
+// The usual arguments are added for known players given without arguments.
+func CreateCommandPlayer(command string) *CommandPlayer {
+	fields := strings.Fields(command)
+	p := &CommandPlayer{Name: fields[0], Args: fields[1:]}
+	if args, ok := audioCommands[p.Name]; ok && len(p.Args) == 0 {
+		p.Args = args
+	}
+	return p
+}
+
+func (p *CommandPlayer) Play(fileName string) error {
+	args := append(append([]string{}, p.Args...), fileName)
+	return exec.Command(p.Name, args...).Run()
+}
+
+// SilentPlayer plays nothing.
+type SilentPlayer struct{}
+
+func (p *SilentPlayer) Play(fileName string) error {
+	return nil
+}
+
+// RecordingPlayer plays nothing but remembers the clips it was asked to play, for tests.
+type RecordingPlayer struct {
+	mutex  sync.Mutex
+	played []string
+}
+
+func (p *RecordingPlayer) Play(fileName string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.played = append(p.played, fileName)
+	return nil
+}
+
+// Played returns the clips played so far.
+func (p *RecordingPlayer) Played() []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]string{}, p.played...)
+}
+
+// ParseAudioPlayer returns the player for a -audio flag: "none" for no sound,
+// otherwise the command line of an external player.
+func ParseAudioPlayer(spec string) AudioPlayer {
+	switch strings.TrimSpace(spec) {
+	case "", "none", "silent":
+		return &SilentPlayer{}
+	}
+	return CreateCommandPlayer(spec)
+}
+
+func playAudio(player AudioPlayer, fileName string) {
+	if err := player.Play(fileName); err != nil {
+		log.Printf("Can't play %s: %s", fileName, err.Error())
+	}
+}
@@ -0,0 +1,29 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// TestNarrateVoicePack plays the narration of a phase through a voice pack
+// and checks the clips played.
+func TestNarrateVoicePack(t *testing.T) {
+	pack, err := readVoicePack(fstest.MapFS{
+		voicePackManifest: {Data: []byte(`{"phases": {"Werewolf": {"enter": ["wolves/open.mp3", "wolves/kill.mp3"]}}}`)},
+		"wolves/open.mp3": {Data: []byte("open")},
+		"wolves/kill.mp3": {Data: []byte("kill")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack.dir = "pack"
+	player := &RecordingPlayer{}
+	narrate(player, pack, TurnWerewolf, CueLeave)
+	narrate(player, pack, TurnWerewolf, CueEnter)
+	want := []string{filepath.Join("pack", "wolves", "open.mp3"), filepath.Join("pack", "wolves", "kill.mp3")}
+	if played := player.Played(); !reflect.DeepEqual(played, want) {
+		t.Errorf("Played %v, want %v", played, want)
+	}
+}
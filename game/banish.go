@@ -0,0 +1,301 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// PKTie has the tied players give another speech, then the others vote
+	// again among them. A second tie banishes nobody.
+	PKTie = "pk"
+	// NoBanishmentTie banishes nobody on a tie.
+	NoBanishmentTie = "none"
+	// RandomTie banishes one of the tied players at random.
+	RandomTie = "random"
+)
+
+// tieRules are the rules of a tie at the banishment vote, the first one is the default.
+var tieRules = []string{PKTie, NoBanishmentTie, RandomTie}
+
+// voteReason is what the game waits for in the phases of the banishment vote.
+var voteReason = map[int]string{
+	TurnDay:    "Banishment",
+	TurnPKVote: "Revote",
+}
+
+// banishVote is the vote of the day on the player to banish.
+type banishVote struct {
+	votes map[int]int
+	// pk holds the tied players of the first vote, who speak again and are
+	// the only ones voted for at the revote.
+	pk      []int
+	speaker int
+}
+
+// VoteCount is the weighted number of votes for a player.
+type VoteCount struct {
+	Id    int     `json:"id"`
+	Votes float64 `json:"votes"`
+}
+
+type VoteResponse struct {
+	Phase string `json:"phase"`
+	// Votes are the votes cast so far, -1 for an abstention.
+	Votes []TeamVote  `json:"votes"`
+	Tally []VoteCount `json:"tally"`
+	// Pending are the voters who haven't voted yet.
+	Pending []int `json:"pending"`
+	// PK are the tied players speaking again before the revote.
+	PK []int `json:"pk"`
+	// Speaker is the player giving a PK speech or their last words, -1 if none.
+	Speaker int    `json:"speaker"`
+	TieRule string `json:"tieRule"`
+}
+
+// banishVoters returns the players voting at the banishment: the living
+// players with a vote, besides the tied players at the revote.
+func (c *Controller) banishVoters() []int {
+	ids := []int{}
+	for i, r := range c.Roles {
+		if canVote(r) && !isInSlice(i, c.banishVote.pk) {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+// pendingBanishVoters returns the voters who haven't voted yet.
+func (c *Controller) pendingBanishVoters() []int {
+	ids := []int{}
+	for _, id := range c.banishVoters() {
+		if _, ok := c.banishVote.votes[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// canBeBanished reports whether the player may be voted out: a living
+// player, not the revealed Moron, and one of the tied players at the revote.
+func (c *Controller) canBeBanished(id int) bool {
+	if c.banishVote.pk != nil && !isInSlice(id, c.banishVote.pk) {
+		return false
+	}
+	if m, ok := c.Roles[id].(*Moron); ok && m.revealed {
+		return false
+	}
+	return !c.Roles[id].IsDead()
+}
+
+// forgetVotesForTheDead drops the votes for the players who died since they
+// were cast, e.g. a Knight who lost a duel; their voters vote again.
+func (c *Controller) forgetVotesForTheDead() {
+	for id, target := range c.banishVote.votes {
+		if target >= 0 && c.Roles[target].IsDead() {
+			delete(c.banishVote.votes, id)
+		}
+	}
+}
+
+// banishTally returns the weighted votes for every player voted for, the
+// most voted first. Votes of players who lost their vote since don't count.
+func (c *Controller) banishTally() []VoteCount {
+	counts := map[int]float64{}
+	for id, target := range c.banishVote.votes {
+		if target >= 0 {
+			counts[target] += c.voteWeight(id)
+		}
+	}
+	tally := make([]VoteCount, 0, len(counts))
+	for id, votes := range counts {
+		if votes > 0 {
+			tally = append(tally, VoteCount{Id: id, Votes: votes})
+		}
+	}
+	sort.Slice(tally, func(i, j int) bool {
+		if tally[i].Votes != tally[j].Votes {
+			return tally[i].Votes > tally[j].Votes
+		}
+		return tally[i].Id < tally[j].Id
+	})
+	return tally
+}
+
+func (c *Controller) banishVotes() []TeamVote {
+	votes := []TeamVote{}
+	for id, target := range c.banishVote.votes {
+		votes = append(votes, TeamVote{Id: id, Target: target})
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i].Id < votes[j].Id })
+	return votes
+}
+
+func (c *Controller) banishVotesMessage() string {
+	votes := []string{}
+	for _, v := range c.banishVotes() {
+		if v.Target < 0 {
+			votes = append(votes, fmt.Sprintf("%d: abstained", v.Id+1))
+		} else {
+			votes = append(votes, fmt.Sprintf("%d -> %d", v.Id+1, v.Target+1))
+		}
+	}
+	if len(votes) == 0 {
+		return "none"
+	}
+	return strings.Join(votes, ", ")
+}
+
+// countBanishVotes ends the vote: the most voted player is banished, a tie
+// is settled by the tie rule of the game.
+func (c *Controller) countBanishVotes() int {
+	c.announce("Votes for banishment: " + c.banishVotesMessage() + ".")
+	tally := c.banishTally()
+	if len(tally) == 0 {
+		c.announce("Nobody was banished.")
+		return TurnNight
+	}
+	tied := []int{}
+	for _, count := range tally {
+		if count.Votes == tally[0].Votes {
+			tied = append(tied, count.Id)
+		}
+	}
+	if len(tied) == 1 {
+		return c.banish(tied[0])
+	}
+	switch {
+	case c.tieRule == RandomTie:
+		return c.banish(tied[c.rng.Intn(len(tied))])
+	case c.tieRule == PKTie && c.banishVote.pk == nil:
+		sort.Ints(tied)
+		c.banishVote.pk = tied
+		c.announce("Players " + joinIds(tied) + " are tied and speak again before the revote.")
+		return TurnPKSpeech
+	}
+	c.announce("Players " + joinIds(tied) + " are tied, nobody was banished.")
+	return TurnNight
+}
+
+// banish ends the day with the banishment of the player, who gives their
+// last words. The Moron flips the card instead of dying.
+func (c *Controller) banish(id int) int {
+	if m, ok := c.Roles[id].(*Moron); ok {
+		m.reveal()
+		c.announce(fmt.Sprintf("Player %d is the Moron and stays alive, without a vote.", id+1))
+		return TurnNight
+	}
+	c.announce(fmt.Sprintf("Player %d is banished.", id+1))
+	c.kill(id, false)
+	c.triggerKingShot(id)
+	c.lastWords = id
+	return TurnLastWords
+}
+
+// awaitPKSpeech gives the floor to every tied player in turn.
+func (c *Controller) awaitPKSpeech() int {
+	v := c.banishVote
+	for _, id := range v.pk {
+		v.speaker = id
+		c.machine.wait("PK speech", []int{id})
+		if _, ok := c.awaitInput(TurnPKSpeech); !ok {
+			return TurnNight
+		}
+	}
+	v.speaker = -1
+	return TurnPKVote
+}
+
+// awaitPKVote waits for the revote among the tied players.
+func (c *Controller) awaitPKVote() int {
+	c.banishVote.votes = map[int]int{}
+	if len(c.banishVoters()) == 0 {
+		c.announce("Nobody is left to vote, nobody was banished.")
+		return TurnNight
+	}
+	c.machine.wait(voteReason[TurnPKVote], c.pendingBanishVoters())
+	if _, ok := c.awaitInput(TurnPKVote); !ok {
+		return TurnNight
+	}
+	return c.countBanishVotes()
+}
+
+// awaitLastWords waits for the banished player to end their last words.
+func (c *Controller) awaitLastWords() int {
+	c.banishVote.speaker = c.lastWords
+	c.machine.wait("Last words", []int{c.lastWords})
+	c.awaitInput(TurnLastWords)
+	c.banishVote.speaker = -1
+	return c.afterDeaths(TurnNight)
+}
+
+// banishActionCodes returns the actions of the banishment the player may
+// take now, whatever their role.
+func (c *Controller) banishActionCodes(id int) []int {
+	phase := c.machine.current()
+	if c.banishVote == nil {
+		return nil
+	}
+	switch {
+	case (phase == TurnDay || phase == TurnPKVote) && isInSlice(id, c.banishVoters()):
+		return []int{SkillVote, SkillDontUse}
+	case (phase == TurnPKSpeech || phase == TurnLastWords) && id == c.banishVote.speaker:
+		return []int{SkillEndSpeech}
+	}
+	return nil
+}
+
+// banishAct votes, abstains or ends a speech of the banishment.
+func (c *Controller) banishAct(id int, action int, targetId int) (bool, string) {
+	phase := c.machine.current()
+	if !c.machine.awaits(phase) || !isInSlice(action, c.banishActionCodes(id)) {
+		return false, "You can't do that now!"
+	}
+	switch action {
+	case SkillEndSpeech:
+		c.input(phase, id)
+		return true, "Your speech is over."
+	case SkillVote, SkillDontUse:
+		if action == SkillDontUse {
+			targetId = -1
+		} else if !c.canBeBanished(targetId) {
+			return false, fmt.Sprintf("Player %d can't be voted for!", targetId+1)
+		}
+		c.banishVote.votes[id] = targetId
+		if pending := c.pendingBanishVoters(); len(pending) > 0 {
+			c.machine.wait(voteReason[phase], pending)
+		} else {
+			c.input(phase, voteInput)
+		}
+		if targetId < 0 {
+			return true, "You abstained."
+		}
+		return true, "Vote Succeeded!"
+	}
+	return false, "You're not able to use this skill!"
+}
+
+func (c *Controller) voteInfo() *VoteResponse {
+	res := &VoteResponse{
+		Phase:   turnName[c.machine.current()],
+		Votes:   []TeamVote{},
+		Tally:   []VoteCount{},
+		Pending: []int{},
+		PK:      []int{},
+		Speaker: -1,
+		TieRule: c.tieRule,
+	}
+	if v := c.banishVote; v != nil {
+		res.Votes = c.banishVotes()
+		res.Tally = c.banishTally()
+		res.Speaker = v.speaker
+		if v.pk != nil {
+			res.PK = v.pk
+		}
+		if isInSlice(c.machine.current(), []int{TurnDay, TurnPKVote}) {
+			res.Pending = c.pendingBanishVoters()
+		}
+	}
+	return res
+}
@@ -0,0 +1,80 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountBanishVotes(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		sheriff int
+		// pk are the tied players of the first vote, at the revote.
+		pk    []int
+		votes map[int]int
+		next  int
+		// banished are the players who may be banished, none if empty.
+		banished []int
+	}{
+		{"most voted", PKTie, -1, nil, map[int]int{0: 1, 2: 1, 3: 4}, TurnLastWords, []int{1}},
+		{"all abstained", PKTie, -1, nil, map[int]int{0: -1, 2: -1}, TurnNight, nil},
+		{"tie", PKTie, -1, nil, map[int]int{0: 1, 2: 3}, TurnPKSpeech, nil},
+		{"tie at the revote", PKTie, -1, []int{1, 3}, map[int]int{0: 1, 2: 3}, TurnNight, nil},
+		{"most voted at the revote", PKTie, -1, []int{1, 3}, map[int]int{0: 1, 2: 3, 4: 3}, TurnLastWords, []int{3}},
+		{"tie, no banishment", NoBanishmentTie, -1, nil, map[int]int{0: 1, 2: 3}, TurnNight, nil},
+		{"tie, random", RandomTie, -1, nil, map[int]int{0: 1, 2: 3}, TurnLastWords, []int{1, 3}},
+		{"vote of the sheriff", PKTie, 0, nil, map[int]int{0: 1, 2: 3}, TurnLastWords, []int{1}},
+	}
+	for _, test := range tests {
+		c := dealtGame(&InitGameRequest{Roles: map[string]int{"Villager": 4, "Werewolf": 2}, TieRule: test.rule})
+		c.sheriff = test.sheriff
+		c.banishVote = &banishVote{votes: test.votes, pk: test.pk, speaker: -1}
+		if next := c.countBanishVotes(); next != test.next {
+			t.Errorf("%s: next phase %s, want %s", test.name, turnName[next], turnName[test.next])
+		}
+		dead := []int{}
+		for i, r := range c.Roles {
+			if r.IsDead() {
+				dead = append(dead, i)
+			}
+		}
+		switch {
+		case len(test.banished) == 0 && len(dead) > 0:
+			t.Errorf("%s: players %v banished, want nobody", test.name, dead)
+		case len(test.banished) > 0 && (len(dead) != 1 || !isInSlice(dead[0], test.banished)):
+			t.Errorf("%s: players %v banished, want one of %v", test.name, dead, test.banished)
+		}
+		if test.next == TurnPKSpeech && len(c.banishVote.pk) != 2 {
+			t.Errorf("%s: players %v at the revote, want the 2 tied players", test.name, c.banishVote.pk)
+		}
+	}
+}
+
+// TestVotesForTheDeadKnight checks that the votes cast for a Knight who then
+// lost a duel are dropped, their voters voting again.
+func TestVotesForTheDeadKnight(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{Roles: map[string]int{"Villager": 4, "Werewolf": 2, "Knight": 1, "Prophet": 1}})
+	wolves, villagers, knight := seatsOf(c, "Werewolf"), seatsOf(c, "Villager"), seatsOf(c, "Knight")[0]
+	prophet := seatsOf(c, "Prophet")[0]
+	for _, id := range wolves {
+		play(t, c, TurnWerewolf, id, SkillKill, villagers[0])
+	}
+	play(t, c, TurnProphet, prophet, SkillVerifyRole, wolves[0])
+	play(t, c, TurnDay, villagers[1], SkillVote, knight)
+	play(t, c, TurnDay, villagers[2], SkillVote, knight)
+	play(t, c, TurnDay, knight, SkillDuel, villagers[3])
+	awaitPhase(t, c, TurnDay)
+	if pending := c.GetVotes().Pending; !isInSlice(villagers[1], pending) || !isInSlice(villagers[2], pending) {
+		t.Errorf("Waiting on %v, want the voters for the dead Knight to vote again", pending)
+	}
+	for _, id := range append([]int{villagers[1], villagers[2], villagers[3], prophet}, wolves...) {
+		play(t, c, TurnDay, id, SkillDontUse, 0)
+	}
+	awaitPhase(t, c, TurnWerewolf)
+	for _, announcement := range c.GetState().Announcements {
+		if strings.Contains(announcement, "banished") && !strings.Contains(announcement, "Nobody") {
+			t.Errorf("Got %q, want nobody banished", announcement)
+		}
+	}
+}
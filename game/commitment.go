@@ -0,0 +1,39 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+const saltBytes = 32
+
+// CommitDeal returns the commitment published when the game starts: the hex
+// encoded sha256 of the salt and the role names of every seat, in seat order,
+// followed by the extra cards left undealt.
+func CommitDeal(salt string, deal []string) string {
+	sum := sha256.Sum256([]byte(salt + ":" + strings.Join(deal, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+func newSalt() (string, error) {
+	b := make([]byte, saltBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Verify checks the revealed salt and deal against the commitment.
+func (r *PostGameResponse) Verify() bool {
+	seats := append([]PlayerRole{}, r.Deal...)
+	sort.Slice(seats, func(i, j int) bool { return seats[i].Id < seats[j].Id })
+	deal := make([]string, len(seats))
+	for i, s := range seats {
+		deal[i] = s.RoleName
+	}
+	deal = append(deal, r.ExtraCards...)
+	return r.Commitment != "" && CommitDeal(r.Salt, deal) == r.Commitment
+}
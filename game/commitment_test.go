@@ -0,0 +1,52 @@
+package game
+
+import "testing"
+
+// TestVerify checks the revealed deal of a game against its commitment, and
+// that a tampered deal fails.
+func TestVerify(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{
+		Roles:      map[string]int{"Villager": 3, "Werewolf": 2, "Prophet": 1, "Thief": 1},
+		ExtraCards: []string{"Villager", "Hunter"},
+	})
+	playToEnd(t, c)
+	res := c.GetPostGameInfo()
+	if res.Commitment == "" || res.Commitment != c.GetCommitment() {
+		t.Fatalf("Revealed commitment %q, want %q", res.Commitment, c.GetCommitment())
+	}
+	if !res.Verify() {
+		t.Fatal("The revealed deal doesn't match the commitment")
+	}
+
+	wolf, villager := seatsOf(c, "Werewolf")[0], seatsOf(c, "Villager")[0]
+	tests := []struct {
+		name   string
+		tamper func(r *PostGameResponse)
+		valid  bool
+	}{
+		{"seats reordered", func(r *PostGameResponse) {
+			r.Deal[0], r.Deal[1] = r.Deal[1], r.Deal[0]
+		}, true},
+		{"roles swapped", func(r *PostGameResponse) {
+			r.Deal[wolf].RoleName, r.Deal[villager].RoleName = r.Deal[villager].RoleName, r.Deal[wolf].RoleName
+		}, false},
+		{"extra card changed", func(r *PostGameResponse) {
+			r.ExtraCards[0] = "Wizard"
+		}, false},
+		{"salt changed", func(r *PostGameResponse) {
+			r.Salt += "0"
+		}, false},
+		{"no commitment", func(r *PostGameResponse) {
+			r.Salt, r.Commitment = "", ""
+		}, false},
+	}
+	for _, test := range tests {
+		tampered := *res
+		tampered.Deal = append([]PlayerRole{}, res.Deal...)
+		tampered.ExtraCards = append([]string{}, res.ExtraCards...)
+		test.tamper(&tampered)
+		if valid := tampered.Verify(); valid != test.valid {
+			t.Errorf("%s: valid %v, want %v", test.name, valid, test.valid)
+		}
+	}
+}
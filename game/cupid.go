@@ -0,0 +1,53 @@
+package game
+
+import (
+	"sync/atomic"
+)
+
+type Cupid struct {
+	player
+	linked []int
+}
+
+func CreateCupid(id int, c *Controller) *Cupid {
+	return &Cupid{player: newPlayer(id, "Cupid", c)}
+}
+
+func init() {
+	registerRole(&roleSpec{
+		name:       "Cupid",
+		faction:    FactionGod,
+		nightTurns: []int{TurnCupid},
+		abilities:  []int{SkillLink},
+		max:        1,
+		create:     func(id int, c *Controller) Role { return CreateCupid(id, c) },
+	})
+}
+
+func (v *Cupid) GetActionCode() (bool, []int) {
+	if atomic.LoadInt32(v.controller.phase) != TurnCupid || v.dead || len(v.linked) >= 2 {
+		return false, nil
+	}
+	return true, []int{SkillLink}
+}
+
+// Act links the target to the other lover. Cupid picks the two lovers one
+// after the other.
+func (v *Cupid) Act(action int, targetId int) (bool, string) {
+	if !v.controller.machine.awaits(TurnCupid) || v.dead {
+		return false, "Not your turn!"
+	}
+	if action != SkillLink {
+		return false, "You're not able to use this skill!"
+	}
+	if isInSlice(targetId, v.linked) {
+		return false, "Target is already chosen!"
+	}
+	v.linked = append(v.linked, targetId)
+	if len(v.linked) < 2 {
+		return true, "Choose the second lover."
+	}
+	v.controller.link(v.linked[0], v.linked[1])
+	v.controller.input(TurnCupid, targetId)
+	return true, "Link Succeeded!"
+}
@@ -0,0 +1,217 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// TimeoutSkip gives up the action of the phase: no kill, no protection,
+	// no potion, no check or no shot. Players who haven't run for sheriff
+	// don't, speeches end, votes are blank and the badge is torn up. The
+	// banishment is decided by the votes cast so far.
+	TimeoutSkip = "skip"
+	// TimeoutRandom acts on a random living player.
+	TimeoutRandom = "random"
+)
+
+// hurryUpBefore is how long before the deadline of a phase the players are told to hurry up.
+const hurryUpBefore = 10 * time.Second
+
+// timedTurns are the phases which may have a deadline, with the actions
+// allowed when it passes. The first one is the default.
+var timedTurns = map[int][]string{
+	TurnWerewolf:         {TimeoutSkip, TimeoutRandom},
+	TurnGuard:            {TimeoutSkip, TimeoutRandom},
+	TurnWizard:           {TimeoutSkip},
+	TurnProphet:          {TimeoutRandom, TimeoutSkip},
+	TurnDay:              {TimeoutSkip},
+	TurnHunterShot:       {TimeoutSkip},
+	TurnCupid:            {TimeoutRandom, TimeoutSkip},
+	TurnThief:            {TimeoutSkip, TimeoutRandom},
+	TurnSheriffCandidacy: {TimeoutSkip},
+	TurnSheriffSpeech:    {TimeoutSkip},
+	TurnSheriffVote:      {TimeoutSkip},
+	TurnBadge:            {TimeoutSkip},
+	TurnPKSpeech:         {TimeoutSkip},
+	TurnPKVote:           {TimeoutSkip},
+	TurnLastWords:        {TimeoutSkip},
+}
+
+// turnByName returns the phase with the given name.
+func turnByName(name string) (int, bool) {
+	for turn, n := range turnName {
+		if n == name {
+			return turn, true
+		}
+	}
+	return 0, false
+}
+
+// validateTimeouts checks the phase timeouts and timeout actions of an InitGameRequest.
+func validateTimeouts(timeouts map[string]int, actions map[string]string) bool {
+	for name, seconds := range timeouts {
+		turn, ok := turnByName(name)
+		if _, timed := timedTurns[turn]; !ok || !timed || seconds < 0 {
+			return false
+		}
+	}
+	for name, action := range actions {
+		turn, ok := turnByName(name)
+		if !ok || !isInStringSlice(action, timedTurns[turn]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isInStringSlice(t string, s []string) bool {
+	for _, n := range s {
+		if t == n {
+			return true
+		}
+	}
+	return false
+}
+
+// setupTimeouts reads the deadlines of the phases from the request.
+func (c *Controller) setupTimeouts(sgr *InitGameRequest) {
+	c.timeouts = map[int]time.Duration{}
+	c.timeoutActions = map[int]string{}
+	for turn, actions := range timedTurns {
+		c.timeoutActions[turn] = actions[0]
+	}
+	for name, seconds := range sgr.PhaseTimeouts {
+		turn, _ := turnByName(name)
+		c.timeouts[turn] = time.Duration(seconds) * time.Second
+	}
+	for name, action := range sgr.TimeoutActions {
+		turn, _ := turnByName(name)
+		c.timeoutActions[turn] = action
+	}
+}
+
+// startDeadline starts the clock of the turn, if it has a deadline. It returns
+// the channels firing when the players should hurry up and when time is up.
+func (c *Controller) startDeadline(turn int) (hurry <-chan time.Time, expired <-chan time.Time, stop func()) {
+	timeout := c.timeouts[turn]
+	if timeout <= 0 {
+		return nil, nil, func() {}
+	}
+	c.deadline = time.Now().Add(timeout)
+	expiredTimer := time.NewTimer(timeout)
+	stops := []func() bool{expiredTimer.Stop}
+	if timeout > hurryUpBefore {
+		hurryTimer := time.NewTimer(timeout - hurryUpBefore)
+		stops = append(stops, hurryTimer.Stop)
+		hurry = hurryTimer.C
+	}
+	return hurry, expiredTimer.C, func() {
+		c.deadline = time.Time{}
+		for _, stop := range stops {
+			stop()
+		}
+	}
+}
+
+// remainingSeconds returns the time left to act in the current phase, or 0 if it has no deadline.
+func (c *Controller) remainingSeconds() int {
+	if c.deadline.IsZero() {
+		return 0
+	}
+	left := time.Until(c.deadline)
+	if left < 0 {
+		return 0
+	}
+	return int(left.Seconds() + 0.5)
+}
+
+// expire makes the turn time out now, e.g. when a timeout is replayed.
+func (c *Controller) expire(turn int) {
+	c.query(func() interface{} {
+		if c.machine.awaits(turn) {
+			c.timedOut = true
+		}
+		return nil
+	})
+}
+
+// timeoutInput returns the input used when nobody acted in the turn before its deadline.
+func (c *Controller) timeoutInput(turn int) int {
+	log.Printf("Time is up for %s, action: %s", turnName[turn], c.timeoutActions[turn])
+	random := c.timeoutActions[turn] == TimeoutRandom
+	switch turn {
+	case TurnWerewolf:
+		if killedId, ok := c.wolfVoteResult(true); ok {
+			return killedId
+		}
+		if random {
+			return c.randomPlayer(func(r Role) bool { return r.GetFaction() != FactionWerewolf })
+		}
+	case TurnGuard:
+		if !random {
+			return -1
+		}
+		for _, id := range c.playersInTurn(TurnGuard) {
+			return c.randomPlayer(func(r Role) bool {
+				ok, _ := c.Roles[id].(*Guard).canProtect(r)
+				return ok
+			})
+		}
+	case TurnWizard:
+		return -2
+	case TurnCupid:
+		if !random {
+			return -1
+		}
+		for _, id := range c.playersInTurn(TurnCupid) {
+			cupid := c.Roles[id].(*Cupid)
+			for len(cupid.linked) < 2 {
+				cupid.linked = append(cupid.linked, c.randomPlayer(func(r Role) bool {
+					return len(cupid.linked) == 0 || r != c.Roles[cupid.linked[0]]
+				}))
+			}
+			c.link(cupid.linked[0], cupid.linked[1])
+			return cupid.linked[1]
+		}
+	case TurnThief:
+		for _, id := range c.playersInTurn(TurnThief) {
+			if !random && !c.thiefMustSwap() {
+				return -1
+			}
+			card := c.rng.Intn(len(c.extraCards))
+			role := c.swapRole(id, card)
+			c.notices[id] = fmt.Sprintf("Time was up, you took a random card. You are now the %s.", role.GetRoleName())
+			return card
+		}
+	case TurnDay, TurnPKVote:
+		return voteInput
+	case TurnProphet:
+		if !random {
+			return -1
+		}
+		for _, id := range c.playersInTurn(TurnProphet) {
+			targetId := c.randomPlayer(func(r Role) bool { return r != c.Roles[id] })
+			if targetId >= 0 {
+				c.notices[id] = "Time was up, a random player was checked. " + c.Roles[id].(*Prophet).verify(targetId)
+			}
+			return targetId
+		}
+	}
+	return -1
+}
+
+// randomPlayer returns a random living player matching the filter, or -1 if there is none.
+func (c *Controller) randomPlayer(filter func(r Role) bool) int {
+	ids := []int{}
+	for i, r := range c.Roles {
+		if !r.IsDead() && filter(r) {
+			ids = append(ids, i)
+		}
+	}
+	if len(ids) == 0 {
+		return -1
+	}
+	return ids[c.rng.Intn(len(ids))]
+}
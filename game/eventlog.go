@@ -0,0 +1,212 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GameLogDir is where the event log of every game is written.
+var GameLogDir = "./games"
+
+const (
+	EventInit     = "init"
+	EventRegister = "register"
+	EventStart    = "start"
+	EventAction   = "action"
+	EventBanish   = "banish"
+	EventTimeout  = "timeout"
+	EventGameOver = "gameover"
+	EventStop     = "stop"
+)
+
+// Event is one state change of a game, appended to the game's log as a line of JSON.
+type Event struct {
+	Type     string           `json:"type"`
+	Time     time.Time        `json:"time"`
+	Phase    int              `json:"phase"`
+	Init     *InitGameRequest `json:"init,omitempty"`
+	Deal     []string         `json:"deal,omitempty"`
+	Register *RegisterRequest `json:"register,omitempty"`
+	Salt     string           `json:"salt,omitempty"`
+	Action   *ActionRequest   `json:"action,omitempty"`
+	BanishId int              `json:"banishId,omitempty"`
+}
+
+type eventLog struct {
+	mutex *sync.Mutex
+	file  *os.File
+}
+
+func createEventLog(dir string) (*eventLog, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	name := filepath.Join(dir, fmt.Sprintf("%d.log", time.Now().UnixNano()))
+	return openEventLog(name)
+}
+
+func openEventLog(name string) (*eventLog, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &eventLog{
+		mutex: &sync.Mutex{},
+		file:  f,
+	}, nil
+}
+
+// append writes the event and flushes it to disk before returning.
+func (l *eventLog) append(e *Event) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	eventBytes, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if _, err = l.file.Write(append(eventBytes, '\n')); err != nil {
+		return err
+	}
+	return l.file.Sync()
+}
+
+func (l *eventLog) close() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.file.Close()
+}
+
+// ReadEventLog reads all events of a game log.
+func ReadEventLog(name string) ([]*Event, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	events := []*Event{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		e := &Event{}
+		if err := json.Unmarshal([]byte(line), e); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// Replay rebuilds a controller by applying the events in order. onStep, if
+// not nil, is called after every event is applied. Narration is muted and
+// deadlines are off while replaying.
+func Replay(mode string, events []*Event, onStep func(e *Event, c *Controller)) *Controller {
+	c := CreateController(mode)
+	c.logDir = ""
+	c.mute(true)
+	c.setReplaying(true)
+	for _, e := range events {
+		c.apply(e)
+		if onStep != nil {
+			onStep(e, c)
+		}
+	}
+	c.setReplaying(false)
+	c.mute(false)
+	return c
+}
+
+func (c *Controller) apply(e *Event) {
+	switch e.Type {
+	case EventInit:
+		c.Initialize(e.Init)
+		deal, _ := c.query(func() interface{} { return c.deal }).([]string)
+		if strings.Join(deal, ",") != strings.Join(e.Deal, ",") {
+			log.Printf("Replayed deal %v differs from logged deal %v", deal, e.Deal)
+		}
+	case EventRegister:
+		c.Register(e.Register)
+	case EventStart:
+		c.startWithSalt(e.Salt)
+	case EventAction:
+		c.machine.awaitPhase(e.Phase)
+		c.HandleAction(e.Action.Id, e.Action.ActionCode, e.Action.Target)
+	case EventBanish:
+		c.machine.awaitPhase(TurnDay)
+		c.BanishPlayer(e.BanishId)
+	case EventTimeout:
+		c.machine.awaitPhase(e.Phase)
+		c.expire(e.Phase)
+	case EventGameOver:
+		c.machine.awaitPhase(TurnGameOver)
+	}
+}
+
+// setReplaying turns the deadlines of the phases off while the game is
+// replayed, and back on once it is done.
+func (c *Controller) setReplaying(replaying bool) {
+	c.query(func() interface{} {
+		c.replaying = replaying
+		return nil
+	})
+}
+
+// RecoverController rebuilds the controller of the latest unfinished game
+// logged in dir, or creates a new controller logging to dir if there is none.
+func RecoverController(mode string, dir string) *Controller {
+	c := CreateController(mode)
+	c.logDir = dir
+	names, err := filepath.Glob(filepath.Join(dir, "*.log"))
+	if err != nil || len(names) == 0 {
+		return c
+	}
+	sort.Strings(names)
+	latest := names[len(names)-1]
+	events, err := ReadEventLog(latest)
+	if err != nil {
+		log.Printf("Can't read game log %s: %s", latest, err.Error())
+		return c
+	}
+	if len(events) == 0 {
+		return c
+	}
+	switch events[len(events)-1].Type {
+	case EventGameOver, EventStop:
+		return c
+	}
+
+	log.Printf("Recovering game from %s", latest)
+	c.Stop()
+	c = Replay(mode, events, nil)
+	l, err := openEventLog(latest)
+	if err != nil {
+		log.Printf("Can't reopen game log %s: %s", latest, err.Error())
+	}
+	c.query(func() interface{} {
+		c.logDir = dir
+		c.events = l
+		return nil
+	})
+	return c
+}
+
+// record appends the event to the game log, if the game has one.
+func (c *Controller) record(e *Event) {
+	if c.events == nil {
+		return
+	}
+	e.Time = time.Now()
+	if err := c.events.append(e); err != nil {
+		log.Printf("Can't write game log: %s", err.Error())
+	}
+}
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// playToEnd plays the game with playWaiting until it is over.
+func playToEnd(t *testing.T, c *Controller) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for c.GetState().Phase != TurnGameOver {
+		if time.Now().After(deadline) {
+			t.Fatalf("The game didn't end, stuck in %+v", c.GetState())
+		}
+		playWaiting(c)
+	}
+}
+
+// seatStates returns the role and whether every player is dead.
+func seatStates(c *Controller) []string {
+	states, _ := c.query(func() interface{} {
+		states := []string{}
+		for _, r := range c.Roles {
+			state := r.GetRoleName()
+			if r.IsDead() {
+				state += " (dead)"
+			}
+			states = append(states, state)
+		}
+		return states
+	}).([]string)
+	return states
+}
+
+// TestReplay checks that replaying the log of a game rebuilds its final state.
+func TestReplay(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{
+		Roles:   map[string]int{"Villager": 3, "Werewolf": 2, "Prophet": 1, "Wizard": 1, "Hunter": 1},
+		Sheriff: true,
+	})
+	playToEnd(t, c)
+	logDir, _ := c.query(func() interface{} { return c.logDir }).(string)
+	names, err := filepath.Glob(filepath.Join(logDir, "*.log"))
+	if err != nil || len(names) != 1 {
+		t.Fatalf("Game logs %v (%v), want one", names, err)
+	}
+	events, err := ReadEventLog(names[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan *Controller)
+	go func() { done <- Replay(ReplayMode, events, nil) }()
+	var replayed *Controller
+	select {
+	case replayed = <-done:
+		defer replayed.Stop()
+	case <-time.After(10 * time.Second):
+		t.Fatal("The replay didn't end")
+	}
+	state, want := replayed.GetState(), c.GetState()
+	if state.Phase != TurnGameOver || state.Day != want.Day || !reflect.DeepEqual(state.Victory, want.Victory) {
+		t.Errorf("Replayed %+v, want %+v", state, want)
+	}
+	if seats, want := seatStates(replayed), seatStates(c); !reflect.DeepEqual(seats, want) {
+		t.Errorf("Replayed seats %v, want %v", seats, want)
+	}
+	if commitment, want := replayed.GetCommitment(), c.GetCommitment(); commitment != want {
+		t.Errorf("Replayed commitment %s, want %s", commitment, want)
+	}
+}
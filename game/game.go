@@ -5,10 +5,8 @@ import (
 	"log"
 	"math/rand"
 	"net/http"
-	"os/exec"
 	"strconv"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -17,6 +15,8 @@ const (
 	ServerMode = "server"
 	ClientMode = "client"
 	LocalMode  = "local"
+	ReplayMode = "replay"
+	VerifyMode = "verify"
 )
 
 const (
@@ -28,16 +28,36 @@ const (
 	SkillFire
 	SkillProtect
 	SkillDontUse
+	SkillExplode
+	SkillLink
+	SkillSwap
+	SkillDuel
+	SkillRun
+	SkillWithdraw
+	SkillEndSpeech
+	SkillVote
+	SkillSpeakingOrder
+	SkillPassBadge
 )
 
 var skillName = map[int]string{
-	SkillKill:       "Kill",
-	SkillSave:       "Save",
-	SkillPoison:     "Poison",
-	SkillVerifyRole: "VerifyRole",
-	SkillProtect:    "Guard",
-	SkillFire:       "Fire",
-	SkillDontUse:    "Don't_use_skill",
+	SkillKill:          "Kill",
+	SkillSave:          "Save",
+	SkillPoison:        "Poison",
+	SkillVerifyRole:    "VerifyRole",
+	SkillProtect:       "Guard",
+	SkillFire:          "Fire",
+	SkillDontUse:       "Don't_use_skill",
+	SkillExplode:       "Explode",
+	SkillLink:          "Link",
+	SkillSwap:          "Swap",
+	SkillDuel:          "Duel",
+	SkillRun:           "Run_for_sheriff",
+	SkillWithdraw:      "Withdraw",
+	SkillEndSpeech:     "End_speech",
+	SkillVote:          "Vote",
+	SkillSpeakingOrder: "Speaking_order",
+	SkillPassBadge:     "Pass_badge",
 }
 
 const (
@@ -53,6 +73,17 @@ const (
 	TurnNightEnd
 	TurnWerewolfEnd
 	TurnGuardEnd
+	TurnHurryUp
+	TurnHunterShot
+	TurnCupid
+	TurnThief
+	TurnSheriffCandidacy
+	TurnSheriffSpeech
+	TurnSheriffVote
+	TurnBadge
+	TurnPKSpeech
+	TurnPKVote
+	TurnLastWords
 )
 
 const (
@@ -61,29 +92,71 @@ const (
 )
 
 type Controller struct {
-	IsEnd         bool
-	VillagerCount int
-	GodCount      int
-	WerewolfCount int
-	ProphetCount  int
-	WizardCount   int
-	HunterCount   int
-	MoronCount    int
-	GuardCount    int
-	TotalCount    int
-	WhiteWolfCount int
-	initialized   bool
-	started       bool
-	Roles         []Role // id -> RoleName
-	Passwords     []string
-	mutex         *sync.Mutex
-	phase         *int32
-	waitChan      []chan int
-	lastNight     []string
-	killedTonight int
-	gameMode      string
-	clientChan    chan int
-	hasGuard      bool
+	IsEnd      bool
+	TotalCount int
+	// RoleCounts is the number of players alive of every role.
+	RoleCounts      map[string]int
+	initialized     bool
+	started         bool
+	Roles           []Role // id -> RoleName
+	Passwords       []string
+	phase           *int32
+	commands        chan command
+	done            chan struct{}
+	inputs          map[int]int
+	lastNight       []string
+	killedTonight   int
+	gameMode        string
+	clientChan      chan *ClientResponse
+	machine         *phaseMachine
+	nightOrder      []int
+	guardedTonight  int
+	savedTonight    bool
+	poisonedTonight int
+	wizardRules     WizardRules
+	guardRules      GuardRules
+	lovers          []int
+	events          *eventLog
+	logDir          string
+	muted           *int32
+	seed            int64
+	rng             *rand.Rand
+	deal            []string
+	extraCards      []string
+	alignments      map[string]string
+	salt            string
+	commitment      string
+	victoryRules    []VictoryRule
+	victory         *Victory
+	timeouts        map[int]time.Duration
+	timeoutActions  map[int]string
+	deadline        time.Time
+	timedOut        bool
+	replaying       bool
+	notices         map[int]string
+	shooters        []int
+	shooting        int
+	shotNext        int
+	announcements   []string
+	explosion       *explosion
+	duel            *duel
+	sheriffRule     bool
+	sheriff         int
+	election        *sheriffElection
+	electionHeld    bool
+	badgeNext       int
+	speakingOrder   []int
+	tieRule         string
+	banishVote      *banishVote
+	lastWords       int
+	wolfVoteRule    string
+	wolfVotes       map[int]int
+	lastWolfVote    int
+	audio           AudioPlayer
+	voicePack       *VoicePack
+	language        string
+	speaker         Speaker
+	narrations      []*Narration
 }
 
 type Role interface {
@@ -95,81 +168,160 @@ type Role interface {
 	GetActionCode() (canAct bool, actionCodes []int)
 	Act(action int, target int) (ok bool, message string)
 	IsDead() bool
+	// GetFaction is the side the player wins with: werewolf, villager, god or third party.
+	GetFaction() string
+	// GetAlignment is what information roles, e.g. the Prophet, learn about
+	// the player: werewolf or good.
+	GetAlignment() string
 }
 
 func CreateController(mode string) *Controller {
 	c := &Controller{
-		mutex:    &sync.Mutex{},
-		phase:    new(int32),
-		waitChan: make([]chan int, NumTurn),
-		gameMode: mode,
+		phase:     new(int32),
+		commands:  make(chan command),
+		done:      make(chan struct{}),
+		inputs:    map[int]int{},
+		notices:   map[int]string{},
+		audio:     DefaultAudioPlayer,
+		voicePack: getVoicePack(DefaultVoicePack),
+		language:  DefaultLanguage,
+		speaker:   DefaultSpeaker,
+		gameMode:  mode,
+		muted:     new(int32),
+		logDir:    GameLogDir,
 	}
 	if c.gameMode == ServerMode {
-		c.clientChan = make(chan int, 10)
+		c.clientChan = make(chan *ClientResponse, 10)
 	}
 	*c.phase = TurnNotStarted
-	for i := range c.waitChan {
-		c.waitChan[i] = make(chan int)
-	}
+	c.machine = createPhaseMachine(c.phase)
+	c.registerPhases()
+	go c.loop()
 
 	return c
 }
 
-func (c *Controller) Initialize(sgr *InitGameRequest) bool {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+func (c *Controller) initialize(sgr *InitGameRequest) bool {
 	if c.initialized {
 		return false
 	}
-	// assign vars
-	c.VillagerCount = sgr.VillagerCount
-	c.GodCount = sgr.GuardCount + sgr.MoronCount + sgr.HunterCount + sgr.ProphetCount + sgr.WizardCount
-	c.ProphetCount = sgr.ProphetCount
-	c.WizardCount = sgr.WizardCount
-	c.HunterCount = sgr.HunterCount
-	c.MoronCount = sgr.MoronCount
-	c.GuardCount = sgr.GuardCount
-	c.WerewolfCount = sgr.WerewolfCount
-	c.WhiteWolfCount = sgr.WhiteWolfCount
-	c.TotalCount = c.VillagerCount + c.GodCount + c.WerewolfCount + c.WhiteWolfCount
-	c.hasGuard = sgr.GuardCount > 0
+	if sgr.Seed == nil {
+		seed := time.Now().UnixNano()
+		sgr.Seed = &seed
+	}
+	deal := c.setup(sgr)
+
+	if c.logDir != "" {
+		events, err := createEventLog(c.logDir)
+		if err != nil {
+			log.Printf("Can't create game log: %s", err.Error())
+		}
+		c.events = events
+	}
+	c.record(&Event{Type: EventInit, Init: sgr, Deal: deal})
+	return true
+}
+
+// dealRoles shuffles the cards of the game and returns the role name of every
+// seat, followed by the extra cards left undealt. The roles which must be
+// dealt always get a seat.
+func dealRoles(sgr *InitGameRequest, rng *rand.Rand) []string {
+	cards := []string{}
+	for _, name := range roleNames() {
+		for i := 0; i < sgr.Roles[name]; i++ {
+			cards = append(cards, name)
+		}
+	}
+	cards = append(cards, sgr.ExtraCards...)
+	deal := make([]string, len(cards))
+	for {
+		for i, j := range rng.Perm(len(cards)) {
+			deal[j] = cards[i]
+		}
+		if allDealt(deal[len(deal)-len(sgr.ExtraCards):]) {
+			return deal
+		}
+	}
+}
+
+// allDealt reports whether none of the extra cards is of a role which must be dealt.
+func allDealt(extraCards []string) bool {
+	for _, name := range extraCards {
+		if roleRegistry[name].dealt {
+			return false
+		}
+	}
+	return true
+}
+
+// setup assigns the vars of the game, deals the roles from the seed of the
+// request and returns the deal.
+func (c *Controller) setup(sgr *InitGameRequest) []string {
+	c.seed = *sgr.Seed
+	c.alignments = sgr.Alignments
+	c.sheriffRule = sgr.Sheriff
+	c.sheriff = -1
+	c.rng = rand.New(rand.NewSource(c.seed))
+	deal := dealRoles(sgr, c.rng)
+	c.TotalCount = len(deal) - len(sgr.ExtraCards)
+	c.extraCards = append([]string{}, deal[c.TotalCount:]...)
+	c.RoleCounts = map[string]int{}
+	for _, name := range deal[:c.TotalCount] {
+		c.RoleCounts[name]++
+	}
+	rule := sgr.VictoryRule
+	if rule == "" {
+		rule = SideKillRule
+	}
+	c.victoryRules = []VictoryRule{victoryRules[rule]}
+	if isInStringSlice("Cupid", deal) {
+		c.AddVictoryRule(VictoryRuleFunc(loversWin))
+	}
+	c.setupTimeouts(sgr)
+	c.wizardRules = sgr.WizardRules
+	c.guardRules = sgr.GuardRules
+	if c.wizardRules.SelfSave == "" {
+		c.wizardRules.SelfSave = SelfSaveFirstNight
+	}
+	c.wolfVoteRule = sgr.WolfVoteRule
+	if c.wolfVoteRule == "" {
+		c.wolfVoteRule = wolfVoteRules[0]
+	}
+	c.tieRule = sgr.TieRule
+	if c.tieRule == "" {
+		c.tieRule = tieRules[0]
+	}
+	c.voicePack = getVoicePack(sgr.VoicePack)
+	c.language = sgr.Language
+	if c.language == "" {
+		c.language = c.voicePack.Language
+	}
+	if _, ok := narrationScripts[c.language]; !ok {
+		c.language = DefaultLanguage
+	}
 	// assign roles
 	c.Roles = make([]Role, c.TotalCount)
 	c.Passwords = make([]string, c.TotalCount)
-	rand.Seed(time.Now().Unix())
-	randIds := rand.Perm(c.TotalCount)
-	for i := 0; i < c.TotalCount; i++ {
-		switch {
-		case i < c.VillagerCount:
-			c.Roles[randIds[i]] = CreateVillager(i, c)
-		case i < c.VillagerCount+c.WerewolfCount:
-			c.Roles[randIds[i]] = CreateWerewolf(i, c)
-		case i < c.VillagerCount+c.WerewolfCount+c.ProphetCount:
-			c.Roles[randIds[i]] = CreateProphet(i, c)
-		case i < c.VillagerCount+c.WerewolfCount+c.ProphetCount+c.WizardCount:
-			c.Roles[randIds[i]] = CreateWizard(i, c)
-		case i < c.VillagerCount+c.WerewolfCount+c.ProphetCount+c.WizardCount+c.HunterCount:
-			c.Roles[randIds[i]] = CreateHunter(i, c)
-		case i < c.VillagerCount+c.WerewolfCount+c.ProphetCount+c.WizardCount+c.HunterCount+c.MoronCount:
-			c.Roles[randIds[i]] = CreateMoron(i, c)
-		case i < c.VillagerCount+c.WerewolfCount+c.ProphetCount+c.WizardCount+c.HunterCount+c.MoronCount+c.GuardCount:
-			c.Roles[randIds[i]] = CreateGuard(i, c)
-		case i < c.VillagerCount+c.WerewolfCount+c.ProphetCount+c.WizardCount+c.HunterCount+c.MoronCount+c.GuardCount+c.WhiteWolfCount:
-			c.Roles[randIds[i]] = CreateWhiteWolf(i, c)
+	for i, name := range deal[:c.TotalCount] {
+		c.Roles[i] = roleRegistry[name].create(i, c)
+	}
+	// night order, with the roles of the extra cards the Thief may take
+	c.nightOrder = []int{}
+	for _, turn := range nightTurns {
+		for _, name := range deal {
+			if isInSlice(turn, roleRegistry[name].nightTurns) {
+				c.nightOrder = append(c.nightOrder, turn)
+				break
+			}
 		}
 	}
 
+	c.deal = deal
 	c.initialized = true
-	return true
+	return deal
 }
 
-func (c *Controller) isInitialized() bool {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-	return c.initialized
-}
-
-func (c *Controller) Register(request *RegisterRequest) *RegisterResponse {
+func (c *Controller) register(request *RegisterRequest) *RegisterResponse {
 	role := c.Roles[request.Id]
 	res := &RegisterResponse{
 		Name:     request.Name,
@@ -179,6 +331,7 @@ func (c *Controller) Register(request *RegisterRequest) *RegisterResponse {
 	if role.Register(request.Name) {
 		res.Code = http.StatusOK
 		c.Passwords[request.Id] = request.Password
+		c.record(&Event{Type: EventRegister, Register: request})
 	} else {
 		if c.Passwords[request.Id] != request.Password {
 			res.RoleName = "You can't see other's role."
@@ -188,9 +341,7 @@ func (c *Controller) Register(request *RegisterRequest) *RegisterResponse {
 	return res
 }
 
-func (c *Controller) StartGame() (bool, string) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
+func (c *Controller) startGame(salt string) (bool, string) {
 	if c.started {
 		return false, "Game already started!"
 	}
@@ -208,28 +359,77 @@ func (c *Controller) StartGame() (bool, string) {
 		log.Printf("Player	%d	Name:	%s\n", i+1, r.GetPlayerName())
 	}
 
-	// start game
-	go c.beginNight(1)
+	// commit to the deal
+	c.salt = salt
+	c.commitment = CommitDeal(salt, c.deal)
+
+	// start game before narrating it, the commands served meanwhile seeing
+	// it started; the game loop runs the phases once the command is answered
 	c.started = true
-	atomic.StoreInt32(c.phase, TurnStarted)
+	c.record(&Event{Type: EventStart, Salt: salt})
+	c.machine.transition(TurnStarted)
 	return true, ""
 }
 
-func (c *Controller) HandleAction(id int, action int, target int) *ActionResponse {
+func (c *Controller) handleAction(id int, action int, target int) *ActionResponse {
 	res := &ActionResponse{}
 	switch action {
 	case GetAction:
+		notice, hasNotice := c.notices[id]
+		delete(c.notices, id)
+		if love := c.loveMessage(id); love != "" {
+			notice, hasNotice = strings.TrimSpace(notice+" "+love), true
+		}
 		res.Successful, res.ActionCodes = c.Roles[id].GetActionCode()
+		if codes := append(c.sheriffActionCodes(id), c.banishActionCodes(id)...); len(codes) > 0 {
+			res.Successful, res.ActionCodes = true, append(res.ActionCodes, codes...)
+		}
 		if !res.Successful {
 			res.Message = "You can't use skill now!"
+			if hasNotice {
+				res.Message = notice
+			}
 			return res
 		}
-		// dead info
-		if isInSlice(SkillSave, res.ActionCodes) {
-			res.Message = fmt.Sprintf("Player id=%d is killed tonight.", c.killedTonight+1)
+		res.RemainingSeconds = c.remainingSeconds()
+		if isInSlice(SkillSwap, res.ActionCodes) {
+			res.Message = c.cardsMessage()
+		}
+		// dead info, for the wizard woken up at night
+		if w, ok := c.Roles[id].(*Wizard); ok && c.machine.awaits(TurnWizard) && w.knowsVictim() {
+			if c.killedTonight >= 0 {
+				res.Message = fmt.Sprintf("Player id=%d is killed tonight.", c.killedTonight+1)
+			} else {
+				res.Message = "Nobody is killed tonight."
+			}
+		}
+		// the picks of the pack
+		if isInSlice(SkillKill, res.ActionCodes) {
+			res.TeamVotes = c.teamVotes()
+			res.Message = c.teamVotesMessage()
+		}
+		if hasNotice {
+			res.Message = strings.TrimSpace(notice + " " + res.Message)
 		}
 	default:
-		res.Successful, res.Message = c.Roles[id].Act(action, target)
+		phase := c.machine.current()
+		if isInSlice(phase, sheriffTurns) || action == SkillSpeakingOrder {
+			res.Successful, res.Message = c.sheriffAct(id, action, target)
+		} else if isInSlice(phase, banishTurns) && isInSlice(action, []int{SkillVote, SkillDontUse, SkillEndSpeech}) {
+			res.Successful, res.Message = c.banishAct(id, action, target)
+		} else if isInSlice(action, specOf(c.Roles[id]).abilities) {
+			res.Successful, res.Message = c.Roles[id].Act(action, target)
+		} else {
+			res.Message = "You're not able to use this skill!"
+			return res
+		}
+		if res.Successful {
+			c.record(&Event{
+				Type:   EventAction,
+				Phase:  phase,
+				Action: &ActionRequest{Id: id, ActionCode: action, Target: target},
+			})
+		}
 	}
 	for _, code := range res.ActionCodes {
 		res.ActionName = append(res.ActionName, skillName[code])
@@ -246,35 +446,29 @@ func isInSlice(t int, s []int) bool {
 	return false
 }
 
+// AddVictoryRule adds a rule, e.g. the win of a third faction, checked before the rules of the game.
+func (c *Controller) AddVictoryRule(rule VictoryRule) {
+	c.victoryRules = append([]VictoryRule{rule}, c.victoryRules...)
+}
+
 func (c *Controller) GameIsEnd() bool {
 	if c.IsEnd {
 		return true
 	}
 
-	var leftWerewolf, leftVillager, leftGod int
-
-	for _, role := range c.Roles {
-		if w, ok := role.(*Werewolf); ok && !w.IsDead() {
-			leftWerewolf++
-		} else if w, ok := role.(*WhiteWolf); ok && !w.IsDead(){
-			leftWerewolf++
-		} else if v, ok := role.(*Villager); ok && !v.IsDead() {
-			leftVillager++
-		} else {
-			leftGod++
+	for _, rule := range c.victoryRules {
+		if victory := rule.Check(c); victory != nil {
+			c.victory = victory
+			c.IsEnd = true
+			return true
 		}
 	}
 
-	if leftVillager == 0 || leftVillager == 0 || leftGod == 0 {
-		c.IsEnd = true
-		return true
-	}
-
 	return false
 }
 
-func (c *Controller) BanishPlayer(id int) *DayEndResponse {
-	if atomic.LoadInt32(c.phase) != TurnDay {
+func (c *Controller) banishPlayer(id int) *DayEndResponse {
+	if !c.machine.awaits(TurnDay) {
 		return &DayEndResponse{
 			Successful: false,
 			Message:    "You can only banish player during the day!",
@@ -288,15 +482,27 @@ func (c *Controller) BanishPlayer(id int) *DayEndResponse {
 		}
 	}
 
-	c.waitChan[TurnDay] <- id
+	message := fmt.Sprintf("Successfully banished player %d", id+1)
+	if m, ok := c.Roles[id].(*Moron); ok {
+		if m.revealed {
+			return &DayEndResponse{
+				Successful: false,
+				Message:    fmt.Sprintf("Error: Player %d is the revealed Moron and can't be banished!", id+1),
+			}
+		}
+		message = fmt.Sprintf("Player %d is the Moron: they stay alive but can't vote anymore.", id+1)
+	}
+
+	c.input(TurnDay, id)
+	c.record(&Event{Type: EventBanish, Phase: TurnDay, BanishId: id})
 	return &DayEndResponse{
 		Successful: true,
-		Message:    fmt.Sprintf("Successfully banished player %d", id+1),
+		Message:    message,
 	}
 }
 
-func (c *Controller) GetLastNightInfo() *LastNightResponse {
-	if atomic.LoadInt32(c.phase) != TurnDay {
+func (c *Controller) lastNightInfo() *LastNightResponse {
+	if !isInSlice(int(atomic.LoadInt32(c.phase)), dayTurns) {
 		return &LastNightResponse{
 			Code:    http.StatusForbidden,
 			Message: "You can only get last night info during the day!",
@@ -315,146 +521,343 @@ func (c *Controller) GetLastNightInfo() *LastNightResponse {
 	}
 }
 
-func (c *Controller) beginDay(day int) {
-	//TODO: sync here instead of sleeping
-	c.SleepAndPlayAudio(TurnDay)
-	// Check game over
-	if c.GameIsEnd() {
-		atomic.StoreInt32(c.phase, TurnGameOver)
-		log.Print("Game Over!")
-		c.SleepAndPlayAudio(TurnGameOver)
-		return
+func (c *Controller) registerPhases() {
+	states := c.machine.states
+	states[TurnNight] = &phaseState{
+		await: c.awaitNight,
+	}
+	states[TurnThief] = &phaseState{
+		await: c.awaitThief,
+	}
+	states[TurnCupid] = &phaseState{
+		await: c.awaitCupid,
+	}
+	states[TurnWerewolf] = &phaseState{
+		await: c.awaitWerewolf,
+	}
+	states[TurnGuard] = &phaseState{
+		await: c.awaitGuard,
+	}
+	states[TurnWizard] = &phaseState{
+		await: c.awaitWizard,
+	}
+	states[TurnProphet] = &phaseState{
+		await: c.awaitProphet,
+	}
+	states[TurnNightEnd] = &phaseState{
+		enter: c.settleNight,
+		await: func() int { return c.afterDeaths(c.dayStart()) },
+	}
+	states[TurnSheriffCandidacy] = &phaseState{
+		await: c.awaitSheriffCandidacy,
+	}
+	states[TurnSheriffSpeech] = &phaseState{
+		await: c.awaitSheriffSpeech,
+	}
+	states[TurnSheriffVote] = &phaseState{
+		await: c.awaitSheriffVote,
+	}
+	states[TurnBadge] = &phaseState{
+		await: c.awaitBadge,
+	}
+	states[TurnDay] = &phaseState{
+		await: c.awaitDay,
+	}
+	states[TurnPKSpeech] = &phaseState{
+		await: c.awaitPKSpeech,
+	}
+	states[TurnPKVote] = &phaseState{
+		await: c.awaitPKVote,
+	}
+	states[TurnLastWords] = &phaseState{
+		await: c.awaitLastWords,
+	}
+	states[TurnHunterShot] = &phaseState{
+		await: c.awaitHunterShot,
+	}
+	states[TurnGameOver] = &phaseState{
+		enter: func() {
+			log.Printf("Game Over! %s wins: %s", c.victory.Faction, c.victory.Reason)
+			c.record(&Event{Type: EventGameOver, Phase: TurnGameOver})
+		},
+	}
+	c.machine.narrate = c.narrate
+}
+
+// actsInTurn reports whether the role is woken up in the given night turn.
+func actsInTurn(role Role, turn int) bool {
+	return isInSlice(turn, specOf(role).nightTurns)
+}
+
+// playersInTurn returns the ids of the living players who act in the given turn.
+func (c *Controller) playersInTurn(turn int) []int {
+	ids := []int{}
+	for i, r := range c.Roles {
+		if actsInTurn(r, turn) && !r.IsDead() {
+			ids = append(ids, i)
+		}
 	}
+	return ids
+}
 
-	// day
-	atomic.StoreInt32(c.phase, TurnDay)
-	deadId := <-c.waitChan[TurnDay]
+// nextNightTurn returns the night turn played after the given one. The Thief
+// and Cupid only play on the first night.
+func (c *Controller) nextNightTurn(turn int) int {
+	i := 0
+	if turn != TurnNight {
+		for i < len(c.nightOrder) && c.nightOrder[i] != turn {
+			i++
+		}
+		i++
+	}
+	for ; i < len(c.nightOrder); i++ {
+		if !isInSlice(c.nightOrder[i], firstNightTurns) || c.machine.state().Day == 1 {
+			return c.nightOrder[i]
+		}
+	}
+	return TurnNightEnd
+}
 
-	// end the day
-	c.Roles[deadId].Die(false)
-	go c.beginNight(day + 1)
+// awaitTurn waits for the players of a night turn to act. When none of them
+// is alive the turn is still narrated so nobody can tell, and ok is false.
+func (c *Controller) awaitTurn(turn int) (target int, ok bool) {
+	ids := c.playersInTurn(turn)
+	if len(ids) == 0 {
+		if !c.isMuted() {
+			c.pause(SleepInterval)
+		}
+		return 0, false
+	}
+	c.machine.wait(turnName[turn], ids)
+	return c.awaitInput(turn)
 }
 
-func (c *Controller) beginNight(day int) {
-	//TODO: sync here instead of sleeping
-	c.SleepAndPlayAudio(TurnNight)
+func (c *Controller) awaitNight() int {
 	// Check game over
 	if c.GameIsEnd() {
-		atomic.StoreInt32(c.phase, TurnGameOver)
-		log.Print("Game Over!")
-		c.SleepAndPlayAudio(TurnGameOver)
-		return
+		return TurnGameOver
 	}
 
 	// reset night info
 	c.lastNight = make([]string, 0)
+	c.guardedTonight = -1
+	c.savedTonight = false
+	c.poisonedTonight = -1
+	c.speakingOrder = nil
+	c.banishVote = nil
+	return c.nextNightTurn(TurnNight)
+}
+
+func (c *Controller) awaitThief() int {
+	// the Thief takes an extra card by acting
+	c.awaitTurn(TurnThief)
+	return c.nextNightTurn(TurnThief)
+}
+
+func (c *Controller) awaitCupid() int {
+	// the lovers are linked by Cupid's actions
+	c.awaitTurn(TurnCupid)
+	return c.nextNightTurn(TurnCupid)
+}
+
+func (c *Controller) awaitWerewolf() int {
+	c.wolfVotes = map[int]int{}
+	c.machine.wait(turnName[TurnWerewolf], c.pendingWolves())
+	if killedId, ok := c.awaitInput(TurnWerewolf); ok {
+		c.killedTonight = killedId
+	}
+	return c.nextNightTurn(TurnWerewolf)
+}
 
-	// Werewolf
-	atomic.StoreInt32(c.phase, TurnWerewolf)
-	c.SleepAndPlayAudio(TurnWerewolf)
-	killedId := <-c.waitChan[TurnWerewolf]
-	saved := false
-	protected := false
-	c.killedTonight = killedId
-	c.SleepAndPlayAudio(TurnWerewolfEnd)
-	// Guard
-
-	guardId := -1
-	if c.hasGuard {
-		atomic.StoreInt32(c.phase, TurnGuard)
-		c.SleepAndPlayAudio(TurnGuard)
-		if c.GuardCount > 0 {
-			guardId = <-c.waitChan[TurnGuard]
-			if guardId >= 0 {
-				protected = true
+func (c *Controller) awaitGuard() int {
+	if guardId, ok := c.awaitTurn(TurnGuard); ok {
+		c.guardedTonight = guardId
+		if guardId >= 0 {
+			for _, id := range c.playersInTurn(TurnGuard) {
+				c.Roles[id].(*Guard).remember(guardId)
 			}
-		} else {
-			time.Sleep(SleepInterval)
 		}
-		c.SleepAndPlayAudio(TurnGuardEnd)
+	}
+	return c.nextNightTurn(TurnGuard)
+}
+
+func (c *Controller) awaitWizard() int {
+	// the potions used are kept in savedTonight and poisonedTonight
+	c.awaitTurn(TurnWizard)
+	return c.nextNightTurn(TurnWizard)
+}
+
+func (c *Controller) awaitProphet() int {
+	c.awaitTurn(TurnProphet)
+	return c.nextNightTurn(TurnProphet)
+}
+
+// settleNight applies the kill, save, protection and poison of the night.
+func (c *Controller) settleNight() {
+	c.announcements = nil
+	killedId := c.killedTonight
+	guardId := c.guardedTonight
+	targetId := c.poisonedTonight
+	saved := c.savedTonight
+	protected := guardId >= 0 && guardId == killedId
+	// the victim survives if saved or protected, but both may cancel out
+	dies := !saved && !protected
+	if saved && protected {
+		dies = !c.guardRules.SameTargetSurvives
+	}
+	if killedId >= 0 && dies {
+		c.lastNight = append(c.lastNight, strconv.Itoa(killedId+1))
+		c.kill(killedId, false)
 	}
 
-	// Wizard
-	atomic.StoreInt32(c.phase, TurnWizard)
-	c.SleepAndPlayAudio(TurnWizard)
-	targetId := -2
-	if c.WizardCount > 0 {
-		targetId = <-c.waitChan[TurnWizard]
-		if targetId == -1 { // save
-			saved = true
-		} else if targetId == -2 {
-			// do not use skill
-		} else { // poison
-			defer c.Roles[targetId].Die(true)
+	// poison
+	if targetId >= 0 {
+		if !c.Roles[targetId].IsDead() {
+			c.lastNight = append(c.lastNight, strconv.Itoa(targetId+1))
 		}
-	} else {
-		time.Sleep(SleepInterval)
+		c.kill(targetId, true)
+	}
+}
+
+func (c *Controller) awaitDay() int {
+	// Check game over
+	if c.GameIsEnd() {
+		return TurnGameOver
+	}
+
+	// the votes are kept when the day comes back after a shot or the badge
+	if c.banishVote == nil {
+		c.banishVote = &banishVote{votes: map[int]int{}, speaker: -1}
 	}
-	// Prophet
-	atomic.StoreInt32(c.phase, TurnProphet)
-	c.SleepAndPlayAudio(TurnProphet)
-	if c.ProphetCount > 0 {
-		<-c.waitChan[TurnProphet]
+	c.forgetVotesForTheDead()
+	c.machine.wait(voteReason[TurnDay], c.pendingBanishVoters())
+	deadId, ok := c.awaitInput(TurnDay)
+	for ok && deadId == duelInput {
+		// a wolf lost the duel and the day ends, or the Knight did and the day
+		// goes on once their death is settled
+		if c.settleDuel() {
+			return c.afterDeaths(TurnNight)
+		}
+		if next := c.afterDeaths(TurnDay); next != TurnDay {
+			return next
+		}
+		if c.GameIsEnd() {
+			return TurnGameOver
+		}
+		c.forgetVotesForTheDead()
+		c.machine.wait(voteReason[TurnDay], c.pendingBanishVoters())
+		deadId, ok = c.awaitInput(TurnDay)
+	}
+	if ok && deadId == explodeInput {
+		c.explode()
+		return c.afterDeaths(TurnNight)
+	}
+	if ok && deadId == voteInput {
+		return c.countBanishVotes()
+	}
+	if !ok || deadId < 0 {
+		return TurnNight
+	}
+
+	// the moderator banished the player
+	return c.banish(deadId)
+}
+
+func (c *Controller) postGameInfo() *PostGameResponse {
+	if c.machine.current() != TurnGameOver {
+		return &PostGameResponse{
+			Code:    http.StatusForbidden,
+			Message: "You can only see the deal after the game is over!",
+		}
+	}
+	res := &PostGameResponse{
+		Code:       http.StatusOK,
+		Seed:       c.seed,
+		Salt:       c.salt,
+		Commitment: c.commitment,
+		Victory:    c.victory,
+		Deal:       make([]PlayerRole, 0, len(c.Roles)),
+		Lovers:     c.lovers,
+		ExtraCards: c.deal[c.TotalCount:],
+	}
+	for i, r := range c.Roles {
+		seat := PlayerRole{
+			Id:         i,
+			PlayerName: r.GetPlayerName(),
+			RoleName:   c.deal[i],
+		}
+		if r.GetRoleName() != c.deal[i] {
+			seat.FinalRoleName = r.GetRoleName()
+		}
+		res.Deal = append(res.Deal, seat)
+	}
+	return res
+}
+
+func (c *Controller) state() *StateResponse {
+	res := c.machine.state()
+	res.RemainingSeconds = c.remainingSeconds()
+	res.Announcements = append([]string{}, c.announcements...)
+	if res.Phase == TurnGameOver {
+		res.Victory = c.victory
+	}
+	return res
+}
+
+// mute silences the narration, e.g. while a game is being replayed.
+func (c *Controller) mute(muted bool) {
+	if muted {
+		atomic.StoreInt32(c.muted, 1)
 	} else {
-		time.Sleep(SleepInterval)
+		atomic.StoreInt32(c.muted, 0)
 	}
-	// save, protected
-	if (!saved && !protected) || (!saved && protected && (guardId != killedId)) || (saved && protected && (guardId == killedId)) {
-		c.Roles[killedId].Die(false)
-		c.lastNight = append(c.lastNight, strconv.Itoa(killedId+1))
+}
+
+func (c *Controller) isMuted() bool {
+	return atomic.LoadInt32(c.muted) == 1
+}
+
+// narrate announces the cue of the phase: the narration is kept for the UI,
+// and the clips of the voice pack or its text are played. The game loop keeps
+// serving commands until the narration is over.
+func (c *Controller) narrate(turn int, cue string) {
+	var n *Narration
+	if text := narrationText(c.language, turn, cue); text != "" {
+		n = &Narration{
+			Seq:       len(c.narrations) + 1,
+			Phase:     turn,
+			PhaseName: turnName[turn],
+			Cue:       cue,
+			Day:       c.machine.state().Day,
+			Language:  c.language,
+			Text:      text,
+			Time:      time.Now(),
+		}
+		c.narrations = append(c.narrations, n)
+		if !c.isMuted() {
+			log.Printf("Narration: %s", text)
+		}
 	}
+	if c.isMuted() || (n == nil && len(c.voicePack.clips(turn, cue)) == 0) {
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		switch c.gameMode {
+		case ServerMode:
+			c.clientChan <- &ClientResponse{TurnCode: turn, Cue: cue, VoicePack: c.voicePack.name, Narration: n}
+		case LocalMode:
+			speakOrPlay(c.audio, c.speaker, c.voicePack, turn, cue, n.text())
+		}
+	}()
+	c.serveUntil(done)
+}
 
-	// poison
-	if targetId >= 0 && targetId != killedId {
-		c.lastNight = append(c.lastNight, strconv.Itoa(targetId+1))
-	}
-	c.SleepAndPlayAudio(TurnNightEnd)
-	go c.beginDay(day)
-}
-
-func (c *Controller) SleepAndPlayAudio(turn int) {
-	switch c.gameMode {
-	case ServerMode:
-		log.Println("sening to client channel")
-		c.clientChan <- turn
-		log.Println("sent to client channel")
-	case LocalMode:
-		SleepAndPlayAudio(turn)
-	}
-}
-
-func SleepAndPlayAudio(turn int) {
-	time.Sleep(SleepInterval)
-	switch turn {
-	case TurnNight:
-		PlayAudio("closeEyes.mpg")
-	case TurnWerewolf:
-		PlayAudio("werewolf.mpg")
-	case TurnWizard:
-		PlayAudio("wizard.mpg")
-	case TurnProphet:
-		PlayAudio("wizardEnd.mpg")
-		PlayAudio("prophet.mpg")
-	case TurnNightEnd:
-		PlayAudio("prophetEnd.mpg")
-	case TurnDay:
-		PlayAudio("day.mpg")
-	case TurnGameOver:
-		PlayAudio("gameOver.mpg")
-	case TurnGuard:
-		PlayAudio("guard.mp3")
-	case TurnWerewolfEnd:
-		PlayAudio("werewolfEnd.mpg")
-	case TurnGuardEnd:
-		PlayAudio("guardEnd.mp3")
-	}
-
-}
-
-func PlayAudio(fileName string) {
-	cmd := exec.Command("/bin/bash", "-c", "afplay ./audio/"+fileName)
-	err := cmd.Run()
-	if err != nil {
-		log.Fatal(err.Error())
+// narrationsSince returns the narrations after the given sequence number.
+func (c *Controller) narrationsSince(seq int) []*Narration {
+	if seq < 0 || seq > len(c.narrations) {
+		seq = 0
 	}
+	return append([]*Narration{}, c.narrations[seq:]...)
 }
@@ -1,87 +1,90 @@
 package game
 
 import (
-	"sync"
 	"sync/atomic"
 )
 
-type Guard struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
-}
-
-func CreateGuard(id int, c *Controller) *Guard {
-	return &Guard{
-		id:         id,
-		roleName:   "Guard",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
+// GuardRules are the house rules of the guard.
+type GuardRules struct {
+	// AllowRepeat lets the guard protect the same player two nights in a row.
+	AllowRepeat bool `json:"allowRepeat,omitempty"`
+	// SameTargetSurvives lets the player both guarded and saved survive,
+	// instead of dying as the protection and the potion cancel out.
+	SameTargetSurvives bool `json:"sameTargetSurvives,omitempty"`
+	// NoSelfProtect forbids the guard to protect themselves.
+	NoSelfProtect bool `json:"noSelfProtect,omitempty"`
 }
 
-func (v *Guard) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.GuardCount--
+// protection is the player protected by the guard on a night.
+type protection struct {
+	day      int
+	targetId int
 }
 
-func (v *Guard) IsDead() bool {
-	return v.dead
+type Guard struct {
+	player
+	history []protection
 }
 
-func (v *Guard) GetRoleName() string {
-	return v.roleName
+func CreateGuard(id int, c *Controller) *Guard {
+	return &Guard{player: newPlayer(id, "Guard", c)}
 }
 
-func (v *Guard) GetPlayerName() string {
-	return v.playerName
+func init() {
+	registerRole(&roleSpec{
+		name:       "Guard",
+		faction:    FactionGod,
+		nightTurns: []int{TurnGuard},
+		abilities:  []int{SkillProtect, SkillDontUse},
+		max:        1,
+		create:     func(id int, c *Controller) Role { return CreateGuard(id, c) },
+	})
 }
 
-func (v *Guard) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
+func (v *Guard) GetActionCode() (bool, []int) {
+	if atomic.LoadInt32(v.controller.phase) != TurnGuard || v.dead {
+		return false, nil
 	}
-	v.playerName = name
-	v.registered = true
-	return true
+	return true, []int{SkillProtect, SkillDontUse}
 }
 
-func (v *Guard) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+// canProtect reports whether the guard may protect the target tonight, or why not.
+func (v *Guard) canProtect(target Role) (bool, string) {
+	rules := v.controller.guardRules
+	if target.IsDead() {
+		return false, "Target is already dead!"
+	}
+	if rules.NoSelfProtect && target == Role(v) {
+		return false, "You can't protect yourself!"
+	}
+	if last := len(v.history) - 1; !rules.AllowRepeat && last >= 0 {
+		p := v.history[last]
+		if p.day == v.controller.machine.state().Day-1 && v.controller.Roles[p.targetId] == target {
+			return false, "You can't protect the same player two nights in a row!"
+		}
+	}
+	return true, ""
 }
 
-func (v *Guard) GetActionCode() (bool, []int) {
-	if atomic.LoadInt32(v.controller.phase) != TurnGuard {
-		return false, nil
-	}
-	return true, []int{SkillProtect, SkillDontUse}
+// remember adds the player protected tonight to the history of the guard.
+func (v *Guard) remember(targetId int) {
+	v.history = append(v.history, protection{day: v.controller.machine.state().Day, targetId: targetId})
 }
 
 func (v *Guard) Act(action int, targetId int) (bool, string) {
-	if atomic.LoadInt32(v.controller.phase) != TurnGuard {
+	if !v.controller.machine.awaits(TurnGuard) || v.dead {
 		return false, "Not your turn!"
 	}
 	switch action {
 	case SkillProtect:
 		// guard somebody
-		target := v.controller.Roles[targetId]
-		if target.IsDead() {
-			return false, "Target is already dead!"
+		if ok, reason := v.canProtect(v.controller.Roles[targetId]); !ok {
+			return false, reason
 		}
-		v.controller.waitChan[TurnGuard] <- targetId
+		v.controller.input(TurnGuard, targetId)
 		return true, "Guard Succeeded!"
 	case SkillDontUse:
-		v.controller.waitChan[TurnGuard] <- -1
+		v.controller.input(TurnGuard, -1)
 		return true, "Didn't use any skill!"
 	default:
 		return false, "You're not able to use this skill!"
@@ -0,0 +1,69 @@
+package game
+
+import "testing"
+
+// TestSettleNight checks who dies of the kill of the night, given the
+// protection of the guard and the save of the wizard.
+func TestSettleNight(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     GuardRules
+		guarded   bool
+		saved     bool
+		wantAlive bool
+	}{
+		{"killed", GuardRules{}, false, false, false},
+		{"guarded", GuardRules{}, true, false, true},
+		{"saved", GuardRules{}, false, true, true},
+		{"guarded and saved", GuardRules{}, true, true, false},
+		{"guarded and saved, same target survives", GuardRules{SameTargetSurvives: true}, true, true, true},
+	}
+	for _, test := range tests {
+		c := dealtGame(&InitGameRequest{
+			Roles:      map[string]int{"Villager": 2, "Werewolf": 2, "Guard": 1, "Wizard": 1},
+			GuardRules: test.rules,
+		})
+		victim := seatOf(c, "Villager")
+		c.killedTonight, c.guardedTonight, c.savedTonight, c.poisonedTonight = victim, -1, test.saved, -1
+		if test.guarded {
+			c.guardedTonight = victim
+		}
+		c.settleNight()
+		if alive := !c.Roles[victim].IsDead(); alive != test.wantAlive {
+			t.Errorf("%s: alive %v, want %v", test.name, alive, test.wantAlive)
+		}
+	}
+}
+
+func TestGuardRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules GuardRules
+		// self protects the guard, other a villager; the villager was
+		// protected last night.
+		self bool
+		ok   bool
+	}{
+		{"another player", GuardRules{}, false, false},
+		{"another player, repeat allowed", GuardRules{AllowRepeat: true}, false, true},
+		{"themselves", GuardRules{}, true, true},
+		{"themselves, no self protection", GuardRules{NoSelfProtect: true}, true, false},
+	}
+	for _, test := range tests {
+		c := dealtGame(&InitGameRequest{
+			Roles:      map[string]int{"Villager": 2, "Werewolf": 2, "Guard": 1},
+			GuardRules: test.rules,
+		})
+		guard := c.Roles[seatOf(c, "Guard")].(*Guard)
+		villager := seatOf(c, "Villager")
+		guard.remember(villager)
+		c.machine.day++
+		target := villager
+		if test.self {
+			target = guard.id
+		}
+		if ok, reason := guard.canProtect(c.Roles[target]); ok != test.ok {
+			t.Errorf("%s: %v (%s), want %v", test.name, ok, reason, test.ok)
+		}
+	}
+}
@@ -1,91 +1,46 @@
 package game
 
 import (
-	"sync"
 	"sync/atomic"
 )
 
 type Hunter struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
+	player
 }
 
 func CreateHunter(id int, c *Controller) *Hunter {
-	return &Hunter{
-		id:         id,
-		roleName:   "Hunter",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
-}
-
-func (v *Hunter) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.HunterCount--
-}
-
-func (v *Hunter) IsDead() bool {
-	return v.dead
-}
-
-func (v *Hunter) GetRoleName() string {
-	return v.roleName
+	return &Hunter{player: newPlayer(id, "Hunter", c)}
 }
 
-func (v *Hunter) GetPlayerName() string {
-	return v.playerName
+func init() {
+	registerRole(&roleSpec{
+		name:      "Hunter",
+		faction:   FactionGod,
+		abilities: []int{SkillFire, SkillDontUse},
+		max:       1,
+		create:    func(id int, c *Controller) Role { return CreateHunter(id, c) },
+	})
 }
 
-func (v *Hunter) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
+func (v *Hunter) Die(isPoisoned bool) {
+	v.player.Die(isPoisoned)
+	if !isPoisoned {
+		v.controller.triggerShot(v.id)
 	}
-	v.playerName = name
-	v.registered = true
-	return true
 }
 
-func (v *Hunter) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+// canShoot reports whether the hunter may shoot: not when poisoned.
+func (v *Hunter) canShoot() bool {
+	return !v.isPoisoned
 }
 
 func (v *Hunter) GetActionCode() (bool, []int) {
-	if atomic.LoadInt32(v.controller.phase) != TurnDay {
+	if atomic.LoadInt32(v.controller.phase) != TurnHunterShot || v.controller.shooting != v.id {
 		return false, nil
 	}
-	return true, []int{SkillFire}
+	return true, []int{SkillFire, SkillDontUse}
 }
 
 func (v *Hunter) Act(action int, targetId int) (bool, string) {
-	if atomic.LoadInt32(v.controller.phase) != TurnDay {
-		return false, "Not your turn!"
-	}
-	if action != SkillFire {
-		return false, "You're not able to use this skill!"
-	}
-	if v.isPoisoned {
-		return false, "You're poisoned!"
-	}
-	if targetId == v.id {
-		return false, "You can't fire yourself!"
-	}
-
-	// fire somebody
-	target := v.controller.Roles[targetId]
-	if target.IsDead() {
-		return false, "Target is already dead!"
-	}
-	v.controller.Roles[targetId].Die(false)
-	return true, "Fire Succeeded!"
+	return v.controller.shotAct(v.id, action, targetId)
 }
@@ -0,0 +1,77 @@
+package game
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+type Knight struct {
+	player
+	dueled bool
+}
+
+// duel is the Knight challenging a player during the day.
+type duel struct {
+	knightId int
+	targetId int
+}
+
+func CreateKnight(id int, c *Controller) *Knight {
+	return &Knight{player: newPlayer(id, "Knight", c)}
+}
+
+func init() {
+	registerRole(&roleSpec{
+		name:      "Knight",
+		faction:   FactionGod,
+		abilities: []int{SkillDuel},
+		max:       1,
+		create:    func(id int, c *Controller) Role { return CreateKnight(id, c) },
+	})
+}
+
+func (v *Knight) GetActionCode() (bool, []int) {
+	if atomic.LoadInt32(v.controller.phase) != TurnDay || v.dead || v.dueled {
+		return false, nil
+	}
+	return true, []int{SkillDuel}
+}
+
+// Act challenges the target to a duel, once per game, during the day before
+// the banishment.
+func (v *Knight) Act(action int, targetId int) (bool, string) {
+	if !v.controller.machine.awaits(TurnDay) || v.dead {
+		return false, "You can only duel during the day!"
+	}
+	if action != SkillDuel {
+		return false, "You're not able to use this skill!"
+	}
+	if v.dueled {
+		return false, "You have already dueled!"
+	}
+	if targetId == v.id {
+		return false, "You can't duel yourself!"
+	}
+	if v.controller.Roles[targetId].IsDead() {
+		return false, "Target is already dead!"
+	}
+	v.dueled = true
+	v.controller.duel = &duel{knightId: v.id, targetId: targetId}
+	v.controller.input(TurnDay, duelInput)
+	return true, "Duel Succeeded!"
+}
+
+// settleDuel kills the loser of the duel and returns true if it was a
+// werewolf, which ends the day without banishment.
+func (c *Controller) settleDuel() bool {
+	d := c.duel
+	c.duel = nil
+	if c.Roles[d.targetId].GetFaction() == FactionWerewolf {
+		c.kill(d.targetId, false)
+		c.announce(fmt.Sprintf("The Knight, player %d, dueled player %d, a werewolf, who dies. There is no banishment today.", d.knightId+1, d.targetId+1))
+		return true
+	}
+	c.kill(d.knightId, false)
+	c.announce(fmt.Sprintf("The Knight, player %d, dueled player %d, who is not a werewolf. The Knight dies.", d.knightId+1, d.targetId+1))
+	return false
+}
@@ -0,0 +1,338 @@
+package game
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+const gameStoppedMessage = "Game has been stopped!"
+
+// command is a request to the game loop. execute runs in the loop goroutine,
+// the only goroutine allowed to touch the state of the game, and always
+// replies on the result channel of the command.
+type command interface {
+	execute(c *Controller)
+}
+
+type initCommand struct {
+	request *InitGameRequest
+	result  chan bool
+}
+
+type registerCommand struct {
+	request *RegisterRequest
+	result  chan *RegisterResponse
+}
+
+type startResult struct {
+	ok      bool
+	message string
+}
+
+type startCommand struct {
+	salt   string
+	result chan startResult
+}
+
+type actionCommand struct {
+	id     int
+	action int
+	target int
+	result chan *ActionResponse
+}
+
+type banishCommand struct {
+	id     int
+	result chan *DayEndResponse
+}
+
+type stopCommand struct {
+	result chan bool
+}
+
+// queryCommand runs a function in the loop, mostly to read the state of the game.
+type queryCommand struct {
+	query  func() interface{}
+	result chan interface{}
+}
+
+func (cmd *initCommand) execute(c *Controller) {
+	cmd.result <- c.initialize(cmd.request)
+}
+
+func (cmd *registerCommand) execute(c *Controller) {
+	cmd.result <- c.register(cmd.request)
+}
+
+func (cmd *startCommand) execute(c *Controller) {
+	ok, message := c.startGame(cmd.salt)
+	cmd.result <- startResult{ok: ok, message: message}
+	if ok {
+		c.machine.run(TurnNight)
+	}
+}
+
+func (cmd *actionCommand) execute(c *Controller) {
+	cmd.result <- c.handleAction(cmd.id, cmd.action, cmd.target)
+}
+
+func (cmd *banishCommand) execute(c *Controller) {
+	cmd.result <- c.banishPlayer(cmd.id)
+}
+
+func (cmd *stopCommand) execute(c *Controller) {
+	c.record(&Event{Type: EventStop})
+	if c.events != nil {
+		c.events.close()
+	}
+	c.machine.halt()
+	cmd.result <- true
+}
+
+func (cmd *queryCommand) execute(c *Controller) {
+	cmd.result <- cmd.query()
+}
+
+// loop serves commands until the game is stopped. The phases of the game run
+// inside the loop once it is started, see startCommand.
+func (c *Controller) loop() {
+	defer close(c.done)
+	for !c.machine.isHalted() {
+		c.serve(nil)
+	}
+}
+
+// serve executes the next command and returns true, or returns false if done
+// is closed first.
+func (c *Controller) serve(done <-chan struct{}) bool {
+	select {
+	case cmd := <-c.commands:
+		cmd.execute(c)
+		return true
+	case <-done:
+		return false
+	}
+}
+
+// serveUntil serves commands until done is closed or the game is stopped.
+func (c *Controller) serveUntil(done <-chan struct{}) {
+	for !c.machine.isHalted() && c.serve(done) {
+	}
+}
+
+// pause serves commands for the given duration.
+func (c *Controller) pause(d time.Duration) {
+	done := make(chan struct{})
+	timer := time.AfterFunc(d, func() { close(done) })
+	defer timer.Stop()
+	c.serveUntil(done)
+}
+
+// input gives the game loop the input it is waiting on in the turn.
+func (c *Controller) input(turn int, value int) {
+	c.inputs[turn] = value
+	c.machine.resolve()
+}
+
+// awaitInput serves commands until the input of the turn is given, or until
+// the deadline of the turn passes and the timeout input is used instead. ok is
+// false if the game was stopped first. The deadline only starts once the game
+// is not being replayed, the timeouts of a replay coming from its log.
+func (c *Controller) awaitInput(turn int) (value int, ok bool) {
+	var hurry, expired <-chan time.Time
+	armed, stop := false, func() {}
+	defer func() { stop() }()
+	for !c.machine.isHalted() {
+		if !armed && !c.replaying {
+			armed = true
+			hurry, expired, stop = c.startDeadline(turn)
+		}
+		if value, ok = c.inputs[turn]; ok {
+			delete(c.inputs, turn)
+			return value, true
+		}
+		if c.timedOut {
+			c.timedOut = false
+			c.machine.resolve()
+			c.record(&Event{Type: EventTimeout, Phase: turn})
+			return c.timeoutInput(turn), true
+		}
+		select {
+		case cmd := <-c.commands:
+			cmd.execute(c)
+		case <-hurry:
+			hurry = nil
+			log.Printf("Hurry up! %d seconds left for %s", c.remainingSeconds(), turnName[turn])
+			c.narrate(TurnHurryUp, CueEnter)
+		case <-expired:
+			c.timedOut = true
+		}
+	}
+	return 0, false
+}
+
+// submit hands the command to the game loop; it returns false if the game is
+// stopped and the command will never be executed.
+func (c *Controller) submit(cmd command) bool {
+	select {
+	case c.commands <- cmd:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+// query runs f in the game loop and returns its result, or nil if the game is stopped.
+func (c *Controller) query(f func() interface{}) interface{} {
+	cmd := &queryCommand{query: f, result: make(chan interface{}, 1)}
+	if !c.submit(cmd) {
+		return nil
+	}
+	return <-cmd.result
+}
+
+func (c *Controller) Initialize(sgr *InitGameRequest) bool {
+	cmd := &initCommand{request: sgr, result: make(chan bool, 1)}
+	return c.submit(cmd) && <-cmd.result
+}
+
+func (c *Controller) Register(request *RegisterRequest) *RegisterResponse {
+	cmd := &registerCommand{request: request, result: make(chan *RegisterResponse, 1)}
+	if !c.submit(cmd) {
+		return &RegisterResponse{Id: request.Id, Name: request.Name, Code: http.StatusGone}
+	}
+	return <-cmd.result
+}
+
+func (c *Controller) StartGame() (bool, string) {
+	salt, err := newSalt()
+	if err != nil {
+		return false, "Can't commit to the deal: " + err.Error()
+	}
+	return c.startWithSalt(salt)
+}
+
+func (c *Controller) startWithSalt(salt string) (bool, string) {
+	cmd := &startCommand{salt: salt, result: make(chan startResult, 1)}
+	if !c.submit(cmd) {
+		return false, gameStoppedMessage
+	}
+	res := <-cmd.result
+	return res.ok, res.message
+}
+
+func (c *Controller) HandleAction(id int, action int, target int) *ActionResponse {
+	cmd := &actionCommand{id: id, action: action, target: target, result: make(chan *ActionResponse, 1)}
+	if !c.submit(cmd) {
+		return &ActionResponse{Message: gameStoppedMessage}
+	}
+	return <-cmd.result
+}
+
+// BanishPlayer lets the moderator banish a player during the day, whatever the votes.
+func (c *Controller) BanishPlayer(id int) *DayEndResponse {
+	cmd := &banishCommand{id: id, result: make(chan *DayEndResponse, 1)}
+	if !c.submit(cmd) {
+		return &DayEndResponse{Message: gameStoppedMessage}
+	}
+	return <-cmd.result
+}
+
+// Stop stops the game loop and closes the game log, marking the game as not to be recovered.
+func (c *Controller) Stop() {
+	cmd := &stopCommand{result: make(chan bool, 1)}
+	if c.submit(cmd) {
+		<-cmd.result
+	}
+}
+
+func (c *Controller) GetLastNightInfo() *LastNightResponse {
+	if res, ok := c.query(func() interface{} { return c.lastNightInfo() }).(*LastNightResponse); ok {
+		return res
+	}
+	return &LastNightResponse{Code: http.StatusGone, Message: gameStoppedMessage}
+}
+
+// GetPostGameInfo reveals the seed, the salt and the full deal once the game is over.
+func (c *Controller) GetPostGameInfo() *PostGameResponse {
+	if res, ok := c.query(func() interface{} { return c.postGameInfo() }).(*PostGameResponse); ok {
+		return res
+	}
+	return &PostGameResponse{Code: http.StatusGone, Message: gameStoppedMessage}
+}
+
+// GetPlayers returns the public list of the players.
+func (c *Controller) GetPlayers() []PlayerInfo {
+	players, ok := c.query(func() interface{} { return c.players() }).([]PlayerInfo)
+	if !ok {
+		return []PlayerInfo{}
+	}
+	return players
+}
+
+// Vote casts the vote of a player at the banishment, -1 to abstain.
+func (c *Controller) Vote(id int, target int) *ActionResponse {
+	if target < 0 {
+		return c.HandleAction(id, SkillDontUse, 0)
+	}
+	return c.HandleAction(id, SkillVote, target)
+}
+
+// GetVotes returns the live tally of the banishment vote.
+func (c *Controller) GetVotes() *VoteResponse {
+	if res, ok := c.query(func() interface{} { return c.voteInfo() }).(*VoteResponse); ok {
+		return res
+	}
+	return &VoteResponse{Speaker: -1}
+}
+
+// GetSheriff returns the sheriff and the state of the election.
+func (c *Controller) GetSheriff() *SheriffResponse {
+	if res, ok := c.query(func() interface{} { return c.sheriffInfo() }).(*SheriffResponse); ok {
+		return res
+	}
+	return &SheriffResponse{Sheriff: -1, Speaker: -1}
+}
+
+// GetNarrations returns the narrations of the game after the given sequence number.
+func (c *Controller) GetNarrations(since int) []*Narration {
+	narrations, ok := c.query(func() interface{} { return c.narrationsSince(since) }).([]*Narration)
+	if !ok {
+		return []*Narration{}
+	}
+	return narrations
+}
+
+// GetState returns the current phase of the game and who it is waiting on.
+func (c *Controller) GetState() *StateResponse {
+	if res, ok := c.query(func() interface{} { return c.state() }).(*StateResponse); ok {
+		return res
+	}
+	return c.machine.state()
+}
+
+// GetCommitment returns the commitment to the deal published when the game started.
+func (c *Controller) GetCommitment() string {
+	commitment, _ := c.query(func() interface{} { return c.commitment }).(string)
+	return commitment
+}
+
+func (c *Controller) isInitialized() bool {
+	initialized, _ := c.query(func() interface{} { return c.initialized }).(bool)
+	return initialized
+}
+
+// PlayerCount returns the number of seats of the game.
+func (c *Controller) PlayerCount() int {
+	count, _ := c.query(func() interface{} { return c.TotalCount }).(int)
+	return count
+}
+
+// checkPassword reports whether the password is the one the player registered with.
+func (c *Controller) checkPassword(id int, password string) bool {
+	ok, _ := c.query(func() interface{} {
+		return id >= 0 && id < c.TotalCount && c.Passwords[id] == password
+	}).(bool)
+	return ok
+}
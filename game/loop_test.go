@@ -0,0 +1,218 @@
+package game
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestGame creates a muted local game with every seat registered, and its
+// event log in a temporary directory. The seed is fixed when not given.
+func newTestGame(t *testing.T, sgr *InitGameRequest) *Controller {
+	t.Helper()
+	logDir := GameLogDir
+	GameLogDir = t.TempDir()
+	c := CreateController(LocalMode)
+	GameLogDir = logDir
+	c.mute(true)
+	t.Cleanup(c.Stop)
+	if sgr.Seed == nil {
+		seed := int64(1)
+		sgr.Seed = &seed
+	}
+	if !c.Initialize(sgr) {
+		t.Fatal("Can't initialize the game")
+	}
+	for i := 0; i < c.PlayerCount(); i++ {
+		c.Register(&RegisterRequest{Id: i, Name: "p", Password: "x"})
+	}
+	return c
+}
+
+// startTestGame creates a game with newTestGame and starts it.
+func startTestGame(t *testing.T, sgr *InitGameRequest) *Controller {
+	t.Helper()
+	c := newTestGame(t, sgr)
+	if ok, msg := c.StartGame(); !ok {
+		t.Fatal(msg)
+	}
+	return c
+}
+
+// playWaiting has every player the game waits on take the first action
+// they may, on the first player it succeeds on, the picks of the pack first.
+// A day nobody is waited on is ended by banishing the first player who may be.
+func playWaiting(c *Controller) {
+	state := c.GetState()
+	if state.Phase == TurnDay && len(state.WaitingOn) == 0 {
+		for id := 0; id < c.PlayerCount(); id++ {
+			if c.BanishPlayer(id).Successful {
+				return
+			}
+		}
+	}
+	for _, id := range state.WaitingOn {
+		res := c.HandleAction(id, GetAction, 0)
+		if !res.Successful {
+			continue
+		}
+		targets := []int{}
+		for _, v := range res.TeamVotes {
+			if v.Target >= 0 {
+				targets = append(targets, v.Target)
+			}
+		}
+		for target := 0; target < c.PlayerCount(); target++ {
+			targets = append(targets, target)
+		}
+		for _, code := range res.ActionCodes {
+			for _, target := range targets {
+				if target != id && c.HandleAction(id, code, target).Successful {
+					return
+				}
+			}
+		}
+	}
+}
+
+// TestConcurrentActions plays a whole game while many goroutines act and
+// read the state at once; run it with -race.
+func TestConcurrentActions(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{
+		Roles:   map[string]int{"Villager": 3, "Werewolf": 2, "Prophet": 1, "Guard": 1},
+		Sheriff: true,
+	})
+	count := c.PlayerCount()
+
+	done := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				id := rng.Intn(count)
+				switch rng.Intn(5) {
+				case 0:
+					c.GetState()
+				case 1:
+					c.GetPlayers()
+				case 2:
+					c.GetVotes()
+				case 3:
+					c.HandleAction(id, GetAction, 0)
+				default:
+					c.HandleAction(id, 1+rng.Intn(len(skillName)), rng.Intn(count))
+				}
+			}
+		}(int64(g))
+	}
+
+	deadline := time.Now().Add(20 * time.Second)
+	for c.GetState().Phase != TurnGameOver {
+		if time.Now().After(deadline) {
+			close(done)
+			wg.Wait()
+			t.Fatalf("The game didn't end, stuck in %+v", c.GetState())
+		}
+		playWaiting(c)
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+
+	if c.GetState().Victory == nil {
+		t.Error("The game is over without a victory")
+	}
+	if res := c.GetPostGameInfo(); len(res.Deal) != count {
+		t.Errorf("Deal of %d players after the game, want %d", len(res.Deal), count)
+	}
+}
+
+// seatsOf returns the ids of the players dealt the role.
+func seatsOf(c *Controller, roleName string) []int {
+	deal, _ := c.query(func() interface{} { return c.deal[:c.TotalCount] }).([]string)
+	ids := []int{}
+	for i, name := range deal {
+		if name == roleName {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+// awaitPhase waits for the game to wait for input in the phase, or to reach
+// it if the phase is final.
+func awaitPhase(t *testing.T, c *Controller, phase int) {
+	t.Helper()
+	reached := make(chan struct{})
+	go func() {
+		c.machine.awaitPhase(phase)
+		close(reached)
+	}()
+	select {
+	case <-reached:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("The game didn't reach %s, stuck in %+v", turnName[phase], c.GetState())
+	}
+}
+
+// play waits for the phase and has the player take the action, which must succeed.
+func play(t *testing.T, c *Controller, phase int, id int, action int, target int) {
+	t.Helper()
+	awaitPhase(t, c, phase)
+	if res := c.HandleAction(id, action, target); !res.Successful {
+		t.Fatalf("Player %d can't %s player %d in %s: %s", id+1, skillName[action], target+1, turnName[phase], res.Message)
+	}
+}
+
+// blockingSpeaker speaks once released.
+type blockingSpeaker struct {
+	release chan struct{}
+}
+
+func (s *blockingSpeaker) Speak(text string) error {
+	<-s.release
+	return nil
+}
+
+// TestConcurrentStarts checks that a start sent while the start of the game
+// is narrated is turned down.
+func TestConcurrentStarts(t *testing.T) {
+	c := newTestGame(t, &InitGameRequest{Roles: map[string]int{"Villager": 2, "Werewolf": 1}})
+	speaker := &blockingSpeaker{release: make(chan struct{})}
+	c.query(func() interface{} {
+		c.speaker = speaker
+		return nil
+	})
+	c.mute(false)
+
+	started := make(chan bool, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			ok, _ := c.StartGame()
+			started <- ok
+		}()
+	}
+	// the second start is served while the first one is narrated
+	for _, want := range []bool{false, true} {
+		select {
+		case ok := <-started:
+			if ok != want {
+				t.Fatalf("Started %v, want %v", ok, want)
+			}
+		case <-time.After(2*SleepInterval + time.Second):
+			t.Fatal("The start didn't return")
+		}
+		if !want {
+			close(speaker.release)
+		}
+	}
+}
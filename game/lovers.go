@@ -0,0 +1,88 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// link makes the two players lovers. Each of them is told who the other one
+// is when asking for their action.
+func (c *Controller) link(a int, b int) {
+	c.lovers = []int{a, b}
+}
+
+// loverOf returns the lover of the player, or -1 if the player is not in love.
+func (c *Controller) loverOf(id int) int {
+	if len(c.lovers) != 2 {
+		return -1
+	}
+	switch id {
+	case c.lovers[0]:
+		return c.lovers[1]
+	case c.lovers[1]:
+		return c.lovers[0]
+	}
+	return -1
+}
+
+// loveMessage tells a lover who the other lover is.
+func (c *Controller) loveMessage(id int) string {
+	lover := c.loverOf(id)
+	if lover < 0 {
+		return ""
+	}
+	return fmt.Sprintf("You are in love with player %d.", lover+1)
+}
+
+// mixedCouple reports whether the lovers are a werewolf and a good player,
+// who play together as a third faction.
+func (c *Controller) mixedCouple() bool {
+	if len(c.lovers) != 2 {
+		return false
+	}
+	wolves := 0
+	for _, id := range c.lovers {
+		if c.Roles[id].GetFaction() == FactionWerewolf {
+			wolves++
+		}
+	}
+	return wolves == 1
+}
+
+// kill makes the player die, and their lover die of a broken heart.
+func (c *Controller) kill(id int, isPoisoned bool) {
+	if c.Roles[id].IsDead() {
+		// the victim of the wolves poisoned too dies poisoned, e.g. the hunter can't shoot
+		if p, ok := c.Roles[id].(interface{ poison() }); ok && isPoisoned {
+			p.poison()
+		}
+		return
+	}
+	c.Roles[id].Die(isPoisoned)
+	lover := c.loverOf(id)
+	if lover < 0 || c.Roles[lover].IsDead() {
+		return
+	}
+	c.Roles[lover].Die(false)
+	if c.machine.current() == TurnNightEnd {
+		c.lastNight = append(c.lastNight, strconv.Itoa(lover+1))
+	} else {
+		c.announce(fmt.Sprintf("Player %d died of a broken heart.", lover+1))
+	}
+}
+
+// loversWin: the lovers win when they are the last players alive.
+func loversWin(c *Controller) *Victory {
+	if len(c.lovers) != 2 {
+		return nil
+	}
+	for i, r := range c.Roles {
+		if !r.IsDead() && c.loverOf(i) < 0 {
+			return nil
+		}
+	}
+	if c.Roles[c.lovers[0]].IsDead() {
+		return nil
+	}
+	return &Victory{Faction: FactionLovers, Reason: "The lovers are the last survivors."}
+}
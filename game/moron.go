@@ -1,62 +1,28 @@
 package game
 
-import (
-	"sync"
-)
-
 type Moron struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
+	player
+	// revealed is set once the Moron is banished: the card is flipped, the
+	// player survives but has no vote for the rest of the game.
+	revealed bool
 }
 
 func CreateMoron(id int, c *Controller) *Moron {
-	return &Moron{
-		id:         id,
-		roleName:   "Moron",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
-}
-
-func (v *Moron) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.MoronCount--
-}
-
-func (v *Moron) IsDead() bool {
-	return v.dead
-}
-
-func (v *Moron) GetRoleName() string {
-	return v.roleName
-}
-
-func (v *Moron) GetPlayerName() string {
-	return v.playerName
+	return &Moron{player: newPlayer(id, "Moron", c)}
 }
 
-func (v *Moron) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
-	}
-	v.playerName = name
-	v.registered = true
-	return true
+func init() {
+	registerRole(&roleSpec{
+		name:    "Moron",
+		faction: FactionGod,
+		max:     1,
+		create:  func(id int, c *Controller) Role { return CreateMoron(id, c) },
+	})
 }
 
-func (v *Moron) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+// reveal flips the card of the Moron instead of banishing them.
+func (v *Moron) reveal() {
+	v.revealed = true
 }
 
 func (v *Moron) GetActionCode() (bool, []int) {
@@ -0,0 +1,144 @@
+package game
+
+import (
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const DefaultLanguage = "en"
+
+// Narration is what the moderator says on entering or leaving a phase.
+type Narration struct {
+	Seq       int       `json:"seq"`
+	Phase     int       `json:"phase"`
+	PhaseName string    `json:"phaseName"`
+	Cue       string    `json:"cue"`
+	Day       int       `json:"day"`
+	Language  string    `json:"language"`
+	Text      string    `json:"text"`
+	Time      time.Time `json:"time"`
+}
+
+func (n *Narration) text() string {
+	if n == nil {
+		return ""
+	}
+	return n.Text
+}
+
+type NarrationResponse struct {
+	Narrations []*Narration `json:"narrations"`
+}
+
+// phaseScript is the text said on entering and leaving a phase.
+type phaseScript struct {
+	enter string
+	leave string
+}
+
+// narrationScripts is the moderator script of every language, by phase name.
+var narrationScripts = map[string]map[string]phaseScript{
+	"en": {
+		"Started":          {enter: "The game begins. Everyone, check your role."},
+		"Night":            {enter: "Night falls. Everyone, close your eyes."},
+		"Thief":            {enter: "Thief, open your eyes. You may swap your card with one of the two extra cards.", leave: "Thief, close your eyes."},
+		"Cupid":            {enter: "Cupid, open your eyes and choose two players to fall in love.", leave: "Cupid, close your eyes."},
+		"Werewolf":         {enter: "Werewolves, open your eyes and choose a player to kill.", leave: "Werewolves, close your eyes."},
+		"Guard":            {enter: "Guard, open your eyes and choose a player to protect.", leave: "Guard, close your eyes."},
+		"Wizard":           {enter: "Wizard, open your eyes. Will you use your antidote or your poison?", leave: "Wizard, close your eyes."},
+		"Prophet":          {enter: "Prophet, open your eyes and choose a player to check.", leave: "Prophet, close your eyes."},
+		"Day":              {enter: "Day breaks. Everyone, open your eyes."},
+		"GameOver":         {enter: "The game is over."},
+		"HurryUp":          {enter: "Hurry up, time is running out."},
+		"HunterShot":       {enter: "A player who died may take another player down.", leave: "The shot has been decided."},
+		"SheriffCandidacy": {enter: "The election of the sheriff begins. Who runs for sheriff?"},
+		"SheriffSpeech":    {enter: "Candidates, give your speech in turn."},
+		"SheriffVote":      {enter: "Everyone who didn't run, vote for the sheriff.", leave: "The vote is over."},
+		"Badge":            {enter: "The sheriff is dead. Pass the badge on or tear it up.", leave: "The badge has been decided."},
+		"PKSpeech":         {enter: "The vote is tied. Tied players, speak again in turn."},
+		"PKVote":           {enter: "Everyone else, vote again among the tied players.", leave: "The revote is over."},
+		"LastWords":        {enter: "The banished player may say their last words."},
+	},
+	"zh": {
+		"Started":          {enter: "游戏开始，请确认你的身份。"},
+		"Night":            {enter: "天黑请闭眼。"},
+		"Thief":            {enter: "盗贼请睁眼，你可以用你的身份牌交换一张底牌。", leave: "盗贼请闭眼。"},
+		"Cupid":            {enter: "丘比特请睁眼，请选择两名玩家成为情侣。", leave: "丘比特请闭眼。"},
+		"Werewolf":         {enter: "狼人请睁眼，请选择要击杀的玩家。", leave: "狼人请闭眼。"},
+		"Guard":            {enter: "守卫请睁眼，请选择要守护的玩家。", leave: "守卫请闭眼。"},
+		"Wizard":           {enter: "女巫请睁眼，你有一瓶解药和一瓶毒药，是否使用？", leave: "女巫请闭眼。"},
+		"Prophet":          {enter: "预言家请睁眼，请选择要查验的玩家。", leave: "预言家请闭眼。"},
+		"Day":              {enter: "天亮了，请睁眼。"},
+		"GameOver":         {enter: "游戏结束。"},
+		"HurryUp":          {enter: "时间快到了，请尽快行动。"},
+		"HunterShot":       {enter: "死亡玩家可以发动技能带走一名玩家。", leave: "技能发动完毕。"},
+		"SheriffCandidacy": {enter: "警长竞选开始，请决定是否上警。"},
+		"SheriffSpeech":    {enter: "请上警的玩家依次发言。"},
+		"SheriffVote":      {enter: "请警下玩家投票选出警长。", leave: "投票结束。"},
+		"Badge":            {enter: "警长死亡，请移交或撕毁警徽。", leave: "警徽处理完毕。"},
+		"PKSpeech":         {enter: "平票，请平票玩家依次进行PK发言。"},
+		"PKVote":           {enter: "请其余玩家在平票玩家中重新投票。", leave: "投票结束。"},
+		"LastWords":        {enter: "请被放逐的玩家发表遗言。"},
+	},
+}
+
+// narrationText returns the text said on the cue of the phase, or "" if there is none.
+func narrationText(language string, turn int, cue string) string {
+	script, ok := narrationScripts[language]
+	if !ok {
+		script = narrationScripts[DefaultLanguage]
+	}
+	if cue == CueLeave {
+		return script[turnName[turn]].leave
+	}
+	return script[turnName[turn]].enter
+}
+
+// Speaker reads the narration aloud when the voice pack has no clip for it.
+type Speaker interface {
+	Speak(text string) error
+}
+
+// DefaultSpeaker is the text-to-speech backend of the local game and of the
+// audio client, nil for none.
+var DefaultSpeaker Speaker
+
+// CommandSpeaker speaks by running an external text-to-speech command, such
+// as espeak or say, with the text as last argument.
+type CommandSpeaker struct {
+	Name string
+	Args []string
+}
+
+func (s *CommandSpeaker) Speak(text string) error {
+	args := append(append([]string{}, s.Args...), text)
+	return exec.Command(s.Name, args...).Run()
+}
+
+// ParseSpeaker returns the speaker for a -tts flag: "" or "none" for no
+// text-to-speech, otherwise the command line of an external program.
+func ParseSpeaker(spec string) Speaker {
+	fields := strings.Fields(spec)
+	if len(fields) == 0 || fields[0] == "none" {
+		return nil
+	}
+	return &CommandSpeaker{Name: fields[0], Args: fields[1:]}
+}
+
+// speakOrPlay narrates a cue: it plays the clips of the voice pack, or speaks
+// the text when the pack has none.
+func speakOrPlay(player AudioPlayer, speaker Speaker, pack *VoicePack, turn int, cue string, text string) {
+	if len(pack.clips(turn, cue)) > 0 {
+		narrate(player, pack, turn, cue)
+		return
+	}
+	if speaker == nil || text == "" {
+		return
+	}
+	time.Sleep(SleepInterval)
+	if err := speaker.Speak(text); err != nil {
+		log.Printf("Can't speak %q: %s", text, err.Error())
+	}
+}
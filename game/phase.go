@@ -0,0 +1,227 @@
+package game
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+var turnName = map[int]string{
+	TurnWerewolf:         "Werewolf",
+	TurnWizard:           "Wizard",
+	TurnProphet:          "Prophet",
+	TurnDay:              "Day",
+	TurnGuard:            "Guard",
+	TurnNotStarted:       "NotStarted",
+	TurnStarted:          "Started",
+	TurnGameOver:         "GameOver",
+	TurnNight:            "Night",
+	TurnNightEnd:         "NightEnd",
+	TurnWerewolfEnd:      "WerewolfEnd",
+	TurnGuardEnd:         "GuardEnd",
+	TurnHurryUp:          "HurryUp",
+	TurnHunterShot:       "HunterShot",
+	TurnCupid:            "Cupid",
+	TurnThief:            "Thief",
+	TurnSheriffCandidacy: "SheriffCandidacy",
+	TurnSheriffSpeech:    "SheriffSpeech",
+	TurnSheriffVote:      "SheriffVote",
+	TurnBadge:            "Badge",
+	TurnPKSpeech:         "PKSpeech",
+	TurnPKVote:           "PKVote",
+	TurnLastWords:        "LastWords",
+}
+
+// nightTurns are the phases in which a role acts at night, in the order
+// they are played when present in the game.
+var nightTurns = []int{TurnThief, TurnCupid, TurnWerewolf, TurnGuard, TurnWizard, TurnProphet}
+
+// firstNightTurns are the night turns only played on the first night.
+var firstNightTurns = []int{TurnThief, TurnCupid}
+
+// sheriffTurns are the phases of the election of the sheriff and of the
+// badge, in which every player may act whatever their role.
+var sheriffTurns = []int{TurnSheriffCandidacy, TurnSheriffSpeech, TurnSheriffVote, TurnBadge}
+
+// banishTurns are the phases of the banishment vote, in which every player
+// may act whatever their role.
+var banishTurns = []int{TurnDay, TurnPKSpeech, TurnPKVote, TurnLastWords}
+
+// dayTurns are the phases played once the night is over, when the deaths of
+// the night are known.
+var dayTurns = []int{TurnDay, TurnHunterShot, TurnSheriffCandidacy, TurnSheriffSpeech, TurnSheriffVote, TurnBadge,
+	TurnPKSpeech, TurnPKVote, TurnLastWords}
+
+// phaseTransitions lists, for every phase, the phases the game may move to next.
+var phaseTransitions = map[int][]int{
+	TurnNotStarted:       {TurnStarted},
+	TurnStarted:          {TurnNight},
+	TurnNight:            {TurnThief, TurnCupid, TurnWerewolf, TurnGameOver},
+	TurnThief:            {TurnCupid, TurnWerewolf},
+	TurnCupid:            {TurnWerewolf},
+	TurnWerewolf:         {TurnGuard, TurnWizard, TurnProphet, TurnNightEnd},
+	TurnGuard:            {TurnWizard, TurnProphet, TurnNightEnd},
+	TurnWizard:           {TurnProphet, TurnNightEnd},
+	TurnProphet:          {TurnNightEnd},
+	TurnNightEnd:         {TurnDay, TurnHunterShot, TurnSheriffCandidacy, TurnBadge},
+	TurnDay:              {TurnNight, TurnGameOver, TurnHunterShot, TurnBadge, TurnPKSpeech, TurnLastWords},
+	TurnHunterShot:       {TurnHunterShot, TurnDay, TurnNight, TurnGameOver, TurnSheriffCandidacy, TurnBadge},
+	TurnSheriffCandidacy: {TurnSheriffSpeech, TurnDay},
+	TurnSheriffSpeech:    {TurnSheriffVote, TurnDay},
+	TurnSheriffVote:      {TurnSheriffVote, TurnDay},
+	TurnBadge:            {TurnHunterShot, TurnDay, TurnNight, TurnSheriffCandidacy},
+	TurnPKSpeech:         {TurnPKVote, TurnNight},
+	TurnPKVote:           {TurnLastWords, TurnNight},
+	TurnLastWords:        {TurnHunterShot, TurnBadge, TurnNight},
+	TurnGameOver:         {},
+}
+
+// phaseState holds the hooks of one phase. enter runs when the phase becomes
+// current, and await blocks until the phase is resolved and returns the next
+// phase. A phase without await is final.
+type phaseState struct {
+	enter func()
+	await func() int
+}
+
+// phaseMachine is the game loop: it walks the phases of a game, checking every
+// transition against phaseTransitions and keeping track of who it waits on.
+type phaseMachine struct {
+	mutex      *sync.Mutex
+	cond       *sync.Cond
+	phase      *int32
+	day        int
+	halted     bool
+	awaiting   bool
+	waitingOn  []int
+	waitingFor string
+	states     map[int]*phaseState
+	// narrate, if set, announces every phase entered and left.
+	narrate func(phase int, cue string)
+}
+
+func createPhaseMachine(phase *int32) *phaseMachine {
+	m := &phaseMachine{
+		mutex:  &sync.Mutex{},
+		phase:  phase,
+		states: map[int]*phaseState{},
+	}
+	m.cond = sync.NewCond(m.mutex)
+	return m
+}
+
+func (m *phaseMachine) current() int {
+	return int(atomic.LoadInt32(m.phase))
+}
+
+func (m *phaseMachine) canTransition(from int, to int) bool {
+	return isInSlice(to, phaseTransitions[from])
+}
+
+// transition moves the machine to the given phase, runs its entry hook and narrates it.
+func (m *phaseMachine) transition(to int) bool {
+	from := m.current()
+	if !m.canTransition(from, to) {
+		log.Printf("Invalid phase transition: %s -> %s", turnName[from], turnName[to])
+		return false
+	}
+	m.mutex.Lock()
+	if to == TurnNight {
+		m.day++
+	}
+	m.awaiting = false
+	m.waitingOn = nil
+	m.waitingFor = ""
+	atomic.StoreInt32(m.phase, int32(to))
+	m.cond.Broadcast()
+	m.mutex.Unlock()
+	if s, ok := m.states[to]; ok && s.enter != nil {
+		s.enter()
+	}
+	if m.narrate != nil {
+		m.narrate(to, CueEnter)
+	}
+	return true
+}
+
+// run drives the game from the given phase until a final phase is reached.
+func (m *phaseMachine) run(from int) {
+	turn := from
+	for m.transition(turn) {
+		s, ok := m.states[turn]
+		if !ok || s.await == nil {
+			return
+		}
+		next := s.await()
+		if m.isHalted() {
+			return
+		}
+		if m.narrate != nil {
+			m.narrate(turn, CueLeave)
+		}
+		turn = next
+	}
+}
+
+// wait records the players the current phase is waiting on.
+func (m *phaseMachine) wait(reason string, ids []int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.awaiting = true
+	m.waitingFor = reason
+	m.waitingOn = ids
+	m.cond.Broadcast()
+}
+
+// resolve records that the input the current phase was waiting on is given.
+func (m *phaseMachine) resolve() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.awaiting = false
+}
+
+// awaits reports whether the machine is waiting for input in the given phase.
+func (m *phaseMachine) awaits(phase int) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.awaiting && m.current() == phase
+}
+
+// halt stops the machine, e.g. when the game is stopped.
+func (m *phaseMachine) halt() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.halted = true
+	m.cond.Broadcast()
+}
+
+func (m *phaseMachine) isHalted() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.halted
+}
+
+// awaitPhase blocks until the machine waits for input in the given phase, or
+// has reached it if the phase is final.
+func (m *phaseMachine) awaitPhase(phase int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	s, ok := m.states[phase]
+	final := !ok || s.await == nil
+	for !m.halted && (m.current() != phase || (!m.awaiting && !final)) {
+		m.cond.Wait()
+	}
+}
+
+func (m *phaseMachine) state() *StateResponse {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	phase := m.current()
+	return &StateResponse{
+		Phase:      phase,
+		PhaseName:  turnName[phase],
+		Day:        m.day,
+		WaitingFor: m.waitingFor,
+		WaitingOn:  append([]int{}, m.waitingOn...),
+	}
+}
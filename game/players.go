@@ -0,0 +1,46 @@
+package game
+
+// PlayerInfo is what everybody at the table knows about a player.
+type PlayerInfo struct {
+	Id         int    `json:"id"`
+	PlayerName string `json:"playerName"`
+	Alive      bool   `json:"alive"`
+	CanVote    bool   `json:"canVote"`
+	Sheriff    bool   `json:"sheriff"`
+	// VoteWeight is what the vote of the player counts for at the banishment.
+	VoteWeight float64 `json:"voteWeight"`
+	// RevealedRole is the role of a player whose card was flipped, e.g. the banished Moron.
+	RevealedRole string `json:"revealedRole,omitempty"`
+}
+
+type PlayersResponse struct {
+	Players []PlayerInfo `json:"players"`
+}
+
+func (c *Controller) players() []PlayerInfo {
+	players := make([]PlayerInfo, 0, len(c.Roles))
+	for i, r := range c.Roles {
+		p := PlayerInfo{
+			Id:         i,
+			PlayerName: r.GetPlayerName(),
+			Alive:      !r.IsDead(),
+			CanVote:    canVote(r),
+			Sheriff:    i == c.sheriff,
+			VoteWeight: c.voteWeight(i),
+		}
+		if m, ok := r.(*Moron); ok && m.revealed {
+			p.RevealedRole = m.GetRoleName()
+		}
+		players = append(players, p)
+	}
+	return players
+}
+
+// canVote reports whether the player still has a vote: not when dead, nor
+// once revealed as the Moron.
+func canVote(r Role) bool {
+	if m, ok := r.(*Moron); ok && m.revealed {
+		return false
+	}
+	return !r.IsDead()
+}
@@ -2,63 +2,26 @@ package game
 
 import (
 	"fmt"
-	"sync"
 	"sync/atomic"
 )
 
 type Prophet struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
+	player
 }
 
 func CreateProphet(id int, c *Controller) *Prophet {
-	return &Prophet{
-		id:         id,
-		roleName:   "Prophet",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
-}
-
-func (v *Prophet) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.ProphetCount--
-}
-
-func (v *Prophet) IsDead() bool {
-	return v.dead
+	return &Prophet{player: newPlayer(id, "Prophet", c)}
 }
 
-func (v *Prophet) GetRoleName() string {
-	return v.roleName
-}
-
-func (v *Prophet) GetPlayerName() string {
-	return v.playerName
-}
-
-func (v *Prophet) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
-	}
-	v.playerName = name
-	v.registered = true
-	return true
-}
-
-func (v *Prophet) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+func init() {
+	registerRole(&roleSpec{
+		name:       "Prophet",
+		faction:    FactionGod,
+		nightTurns: []int{TurnProphet},
+		abilities:  []int{SkillVerifyRole},
+		max:        1,
+		create:     func(id int, c *Controller) Role { return CreateProphet(id, c) },
+	})
 }
 
 func (v *Prophet) GetActionCode() (bool, []int) {
@@ -69,7 +32,7 @@ func (v *Prophet) GetActionCode() (bool, []int) {
 }
 
 func (v *Prophet) Act(action int, targetId int) (bool, string) {
-	if atomic.LoadInt32(v.controller.phase) != TurnProphet {
+	if !v.controller.machine.awaits(TurnProphet) {
 		return false, "Not your turn!"
 	}
 	if action != SkillVerifyRole {
@@ -77,15 +40,12 @@ func (v *Prophet) Act(action int, targetId int) (bool, string) {
 	}
 
 	// verify role of somebody
-	role := v.controller.Roles[targetId]
-	roleMsg := "Good"
-	if _, ok := role.(*Werewolf); ok {
-		roleMsg = "Werewolf"
-	}
-	if _, ok := role.(*WhiteWolf); ok {
-		roleMsg = "Werewolf"
-	}
-	message := fmt.Sprintf("Player %d (%s) is: %s", targetId+1, role.GetPlayerName(), roleMsg)
-	v.controller.waitChan[TurnProphet] <- targetId
+	message := v.verify(targetId)
+	v.controller.input(TurnProphet, targetId)
 	return true, message
 }
+
+func (v *Prophet) verify(targetId int) string {
+	role := v.controller.Roles[targetId]
+	return fmt.Sprintf("Player %d (%s) is: %s", targetId+1, role.GetPlayerName(), role.GetAlignment())
+}
@@ -0,0 +1,186 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// player is what every role has in common: the seat, who sits in it and
+// whether they are still alive.
+type player struct {
+	id         int
+	dead       bool
+	playerName string
+	roleName   string
+	mutex      *sync.Mutex
+	registered bool
+	controller *Controller
+	isPoisoned bool
+	faction    string
+	alignment  string
+}
+
+// newPlayer seats a player of the role, seen as the alignment the game
+// configures for the role, if any.
+func newPlayer(id int, roleName string, c *Controller) player {
+	spec := roleRegistry[roleName]
+	alignment, ok := c.alignments[roleName]
+	if !ok {
+		alignment = spec.alignment()
+	}
+	return player{
+		id:         id,
+		roleName:   roleName,
+		controller: c,
+		mutex:      &sync.Mutex{},
+		faction:    spec.faction,
+		alignment:  alignment,
+	}
+}
+
+func (v *player) Die(isPoisoned bool) {
+	v.dead = true
+	v.isPoisoned = isPoisoned
+	v.controller.RoleCounts[v.roleName]--
+}
+
+// poison marks a player who already died tonight as poisoned too.
+func (v *player) poison() {
+	v.isPoisoned = true
+}
+
+func (v *player) IsDead() bool {
+	return v.dead
+}
+
+func (v *player) GetRoleName() string {
+	return v.roleName
+}
+
+func (v *player) GetPlayerName() string {
+	return v.playerName
+}
+
+func (v *player) Register(name string) bool {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if v.registered {
+		return false
+	}
+	v.playerName = name
+	v.registered = true
+	return true
+}
+
+func (v *player) IsRegistered() bool {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return v.registered
+}
+
+func (v *player) GetFaction() string {
+	return v.faction
+}
+
+func (v *player) GetAlignment() string {
+	return v.alignment
+}
+
+// roleSpec declares a role once: everything the game needs to know about it
+// besides its own GetActionCode and Act.
+type roleSpec struct {
+	name    string
+	faction string
+	// seenAs is the alignment information roles learn about the role, by
+	// default werewolf for the werewolves and good for everybody else.
+	seenAs string
+	// nightTurns are the night turns the role is woken up in, played in the
+	// order of nightTurns.
+	nightTurns []int
+	// abilities are the skills the role may ever use.
+	abilities []int
+	// min and max bound the number of cards of the role in a game, max 0
+	// meaning no bound.
+	min int
+	max int
+	// dealt roles are always dealt to a seat, never left among the extra cards.
+	dealt bool
+	// extraCards is the number of cards added to the deck for every card of
+	// the role, and left undealt.
+	extraCards int
+	create     func(id int, c *Controller) Role
+}
+
+// roleRegistry holds the roles of the game by name. Every role registers
+// itself from the init function of its file.
+var roleRegistry = map[string]*roleSpec{}
+
+func registerRole(spec *roleSpec) {
+	if _, ok := roleRegistry[spec.name]; ok {
+		panic(fmt.Sprintf("role %s registered twice", spec.name))
+	}
+	roleRegistry[spec.name] = spec
+}
+
+// roleNames returns the names of the registered roles, sorted so that the
+// deal only depends on the seed.
+func roleNames() []string {
+	names := make([]string, 0, len(roleRegistry))
+	for name := range roleRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// alignment returns the alignment the role is seen as by default.
+func (s *roleSpec) alignment() string {
+	if s.seenAs != "" {
+		return s.seenAs
+	}
+	if s.faction == FactionWerewolf {
+		return AlignmentWerewolf
+	}
+	return AlignmentGood
+}
+
+// validAlignments checks the alignments of an InitGameRequest: registered
+// roles seen as werewolf or good.
+func validAlignments(alignments map[string]string) bool {
+	for name, alignment := range alignments {
+		if _, ok := roleRegistry[name]; !ok || !isInStringSlice(alignment, []string{AlignmentWerewolf, AlignmentGood}) {
+			return false
+		}
+	}
+	return true
+}
+
+// specOf returns the spec of the role of a player.
+func specOf(role Role) *roleSpec {
+	return roleRegistry[role.GetRoleName()]
+}
+
+// validRoleCounts checks the role counts of an InitGameRequest against the
+// bounds of every role and returns the names of the roles out of bounds. The
+// extra cards count against the max of their role, as the Thief may take them.
+func validRoleCounts(counts map[string]int, extraCards []string) []string {
+	invalid := []string{}
+	for name := range counts {
+		if _, ok := roleRegistry[name]; !ok {
+			invalid = append(invalid, name)
+		}
+	}
+	extra := map[string]int{}
+	for _, name := range extraCards {
+		extra[name]++
+	}
+	for _, name := range roleNames() {
+		spec, count := roleRegistry[name], counts[name]
+		if count < spec.min || (spec.max > 0 && count+extra[name] > spec.max) {
+			invalid = append(invalid, name)
+		}
+	}
+	sort.Strings(invalid)
+	return invalid
+}
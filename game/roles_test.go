@@ -0,0 +1,28 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidRoleCounts(t *testing.T) {
+	tests := []struct {
+		name       string
+		roles      map[string]int
+		extraCards []string
+		invalid    []string
+	}{
+		{"valid", map[string]int{"Villager": 3, "Werewolf": 2, "Hunter": 1}, nil, []string{}},
+		{"no werewolf", map[string]int{"Villager": 3}, nil, []string{"Werewolf"}},
+		{"two hunters", map[string]int{"Villager": 3, "Werewolf": 2, "Hunter": 2}, nil, []string{"Hunter"}},
+		{"unknown role", map[string]int{"Villager": 3, "Werewolf": 2, "Vampire": 1}, nil, []string{"Vampire"}},
+		{"extra cards", map[string]int{"Villager": 3, "Werewolf": 2, "Thief": 1}, []string{"Villager", "Hunter"}, []string{}},
+		{"extra hunter", map[string]int{"Villager": 3, "Werewolf": 2, "Thief": 1, "Hunter": 1}, []string{"Villager", "Hunter"}, []string{"Hunter"}},
+		{"two extra hunters", map[string]int{"Villager": 3, "Werewolf": 2, "Thief": 1}, []string{"Hunter", "Hunter"}, []string{"Hunter"}},
+	}
+	for _, test := range tests {
+		if invalid := validRoleCounts(test.roles, test.extraCards); !reflect.DeepEqual(invalid, test.invalid) {
+			t.Errorf("%s: invalid %v, want %v", test.name, invalid, test.invalid)
+		}
+	}
+}
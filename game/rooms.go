@@ -0,0 +1,291 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultRoom   = "default"
+	roomsEndpoint = "/rooms"
+)
+
+const (
+	roomIdBytes          = 3
+	cleanRoomsInterval   = time.Minute
+	finishedRoomLifetime = 30 * time.Minute
+	abandonedRoomTimeout = 3 * time.Hour
+)
+
+// Room is one table of the server, playing its own game.
+type Room struct {
+	Id         string
+	mode       string
+	mutex      *sync.Mutex
+	controller *Controller
+	created    time.Time
+	lastActive time.Time
+}
+
+type roomHandler func(w http.ResponseWriter, r *http.Request, room *Room)
+
+type RoomInfo struct {
+	Id        string    `json:"id"`
+	PhaseName string    `json:"phaseName"`
+	Day       int       `json:"day"`
+	Players   int       `json:"players"`
+	Created   time.Time `json:"created"`
+}
+
+type CreateRoomResponse struct {
+	Id      string `json:"id"`
+	Message string `json:"message"`
+}
+
+type ListRoomsResponse struct {
+	Rooms []RoomInfo `json:"rooms"`
+}
+
+func (g *GameServer) createRoom(id string) *Room {
+	return g.newRoom(id, createRoomController(g.Mode, id))
+}
+
+// newRoom opens the room with the given controller, e.g. a recovered one.
+func (g *GameServer) newRoom(id string, c *Controller) *Room {
+	return &Room{
+		Id:         id,
+		mode:       g.Mode,
+		mutex:      &sync.Mutex{},
+		controller: c,
+		created:    time.Now(),
+		lastActive: time.Now(),
+	}
+}
+
+func createRoomController(mode string, id string) *Controller {
+	c := CreateController(mode)
+	c.logDir = filepath.Join(GameLogDir, id)
+	return c
+}
+
+func (r *Room) Controller() *Controller {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.controller
+}
+
+// reset stops the game of the room and replaces it with a new one.
+func (r *Room) reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.controller.Stop()
+	r.controller = createRoomController(r.mode, r.Id)
+}
+
+func (r *Room) touch() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.lastActive = time.Now()
+}
+
+// isStale reports whether the room's game is over or nobody has played in it for a long time.
+func (r *Room) isStale(now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	idle := now.Sub(r.lastActive)
+	if r.controller.machine.current() == TurnGameOver && idle > finishedRoomLifetime {
+		return true
+	}
+	return idle > abandonedRoomTimeout
+}
+
+func (r *Room) info() RoomInfo {
+	c := r.Controller()
+	state := c.GetState()
+	return RoomInfo{
+		Id:        r.Id,
+		PhaseName: state.PhaseName,
+		Day:       state.Day,
+		Players:   c.PlayerCount(),
+		Created:   r.created,
+	}
+}
+
+func newRoomId() (string, error) {
+	b := make([]byte, roomIdBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// freeRoomId returns a random room id no room uses yet. The caller holds g.mutex.
+func (g *GameServer) freeRoomId() (string, error) {
+	for {
+		id, err := newRoomId()
+		if err != nil {
+			return "", err
+		}
+		if _, ok := g.rooms[id]; !ok {
+			return id, nil
+		}
+	}
+}
+
+// recoverRooms rebuilds the rooms whose games were interrupted, one per
+// directory of GameLogDir.
+func (g *GameServer) recoverRooms() {
+	infos, err := ioutil.ReadDir(GameLogDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Can't read %s: %s", GameLogDir, err.Error())
+		}
+		return
+	}
+	for _, info := range infos {
+		if !info.IsDir() {
+			continue
+		}
+		c := RecoverController(g.Mode, filepath.Join(GameLogDir, info.Name()))
+		if !c.isInitialized() && info.Name() != DefaultRoom {
+			c.Stop()
+			continue
+		}
+		g.rooms[info.Name()] = g.newRoom(info.Name(), c)
+	}
+}
+
+// cleanRooms periodically closes the rooms that are finished or abandoned.
+func (g *GameServer) cleanRooms() {
+	for now := range time.Tick(cleanRoomsInterval) {
+		g.mutex.Lock()
+		for id, room := range g.rooms {
+			if id == DefaultRoom || !room.isStale(now) {
+				continue
+			}
+			room.Controller().Stop()
+			delete(g.rooms, id)
+			log.Printf("Room %s closed", id)
+		}
+		g.mutex.Unlock()
+	}
+}
+
+func (g *GameServer) getRoom(id string) (*Room, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	room, ok := g.rooms[id]
+	return room, ok
+}
+
+// inRoom serves a room endpoint for the given room.
+func (g *GameServer) inRoom(id string, handler roomHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		room, ok := g.getRoom(id)
+		if !ok {
+			g.writeClientError(w, http.StatusNotFound, "No such room: "+id)
+			return
+		}
+		room.touch()
+		handler(w, r, room)
+	}
+}
+
+// handleRooms creates a room on POST and lists the rooms on GET.
+func (g *GameServer) handleRooms(w http.ResponseWriter, r *http.Request) {
+	var res interface{}
+	switch r.Method {
+	case "POST":
+		g.mutex.Lock()
+		id, err := g.freeRoomId()
+		if err == nil {
+			g.rooms[id] = g.createRoom(id)
+		}
+		g.mutex.Unlock()
+		if err != nil {
+			g.writeServerError(w, err.Error())
+			return
+		}
+		log.Printf("Room %s created", id)
+		res = CreateRoomResponse{
+			Id:      id,
+			Message: "Room successfully created!",
+		}
+	case "GET":
+		g.mutex.Lock()
+		rooms := make([]*Room, 0, len(g.rooms))
+		for _, room := range g.rooms {
+			rooms = append(rooms, room)
+		}
+		g.mutex.Unlock()
+		list := ListRoomsResponse{Rooms: []RoomInfo{}}
+		for _, room := range rooms {
+			list.Rooms = append(list.Rooms, room.info())
+		}
+		sort.Slice(list.Rooms, func(i, j int) bool { return list.Rooms[i].Created.Before(list.Rooms[j].Created) })
+		res = list
+	default:
+		g.writeClientError(w, http.StatusBadRequest, "Only GET and POST are supported")
+		return
+	}
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
+
+// handleRoom serves /rooms/{id}/{endpoint}, and closes the room on DELETE /rooms/{id}.
+func (g *GameServer) handleRoom(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, roomsEndpoint+"/")
+	parts := strings.SplitN(path, "/", 2)
+	id := parts[0]
+	if len(parts) == 1 || parts[1] == "" {
+		g.handleCloseRoom(w, r, id)
+		return
+	}
+	handler, ok := g.roomHandlers()["/"+parts[1]]
+	if !ok {
+		g.writeClientError(w, http.StatusNotFound, "No such endpoint: /"+parts[1])
+		return
+	}
+	g.inRoom(id, handler)(w, r)
+}
+
+func (g *GameServer) handleCloseRoom(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != "DELETE" {
+		g.writeClientError(w, http.StatusBadRequest, "Only DELETE is supported")
+		return
+	}
+	if id == DefaultRoom {
+		g.writeClientError(w, http.StatusForbidden, "The default room can't be closed")
+		return
+	}
+	g.mutex.Lock()
+	room, ok := g.rooms[id]
+	delete(g.rooms, id)
+	g.mutex.Unlock()
+	if !ok {
+		g.writeClientError(w, http.StatusNotFound, "No such room: "+id)
+		return
+	}
+	room.Controller().Stop()
+	log.Printf("Room %s closed", id)
+	resBytes, err := json.Marshal(StopGameResponse{Message: "Room successfully closed!"})
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
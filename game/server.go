@@ -5,7 +5,9 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,7 +21,9 @@ const (
 )
 
 type GameServer struct {
-	Controller *Controller
+	Mode  string
+	mutex *sync.Mutex
+	rooms map[string]*Room
 }
 
 type ErrorResponse struct {
@@ -31,40 +35,100 @@ type InitResponse struct {
 	Message string `json:"message"`
 }
 
+// ClientResponse tells the audio client to narrate the cue of a phase with a voice pack.
 type ClientResponse struct {
-	TurnCode int `json:"turnCode"`
+	TurnCode  int        `json:"turnCode"`
+	Cue       string     `json:"cue"`
+	VoicePack string     `json:"voicePack"`
+	Narration *Narration `json:"narration,omitempty"`
 }
 
 func (g *GameServer) Start() {
-	// TODO: An endpoint to end the day
-	http.HandleFunc("/init", g.handleInit)
-	http.HandleFunc("/start", g.handleStart)
-	http.HandleFunc("/health", g.handleHealth)
-	http.HandleFunc("/register", g.handleRegister)
-	http.HandleFunc("/action", g.handleAction)
-	http.HandleFunc("/lastnightinfo", g.handleLastNight)
-	http.HandleFunc("/dayend", g.handleDayEnd)
-	http.HandleFunc("/home", g.handleHome)
-	http.HandleFunc("/", g.handleHome)
-	if g.Controller.gameMode == ServerMode {
-		http.HandleFunc(ClientEndpoint, g.handleClient)
-	}
-	http.HandleFunc(stopGameEndpoint, g.handleStop)
-	err := http.ListenAndServe(":80", nil)
+	g.rooms = map[string]*Room{}
+	g.mutex = &sync.Mutex{}
+	g.recoverRooms()
+	if _, ok := g.rooms[DefaultRoom]; !ok {
+		g.rooms[DefaultRoom] = g.createRoom(DefaultRoom)
+	}
+	go g.cleanRooms()
+
+	mux := http.NewServeMux()
+	// the endpoints of the default room are also served at the top level
+	for endpoint, handler := range g.roomHandlers() {
+		mux.HandleFunc(endpoint, g.inRoom(DefaultRoom, handler))
+	}
+	mux.HandleFunc("/health", g.handleHealth)
+	mux.HandleFunc("/verify", g.handleVerify)
+	mux.HandleFunc("/home", g.handleHome)
+	mux.HandleFunc("/", g.handleHome)
+	mux.HandleFunc(roomsEndpoint, g.handleRooms)
+	mux.HandleFunc(roomsEndpoint+"/", g.handleRoom)
+	err := http.ListenAndServe(":80", mux)
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
+// roomHandlers returns the endpoints served for every room.
+func (g *GameServer) roomHandlers() map[string]roomHandler {
+	handlers := map[string]roomHandler{
+		"/init":          g.handleInit,
+		"/start":         g.handleStart,
+		"/register":      g.handleRegister,
+		"/action":        g.handleAction,
+		"/lastnightinfo": g.handleLastNight,
+		"/dayend":        g.handleDayEnd,
+		"/vote":          g.handleVote,
+		"/state":         g.handleState,
+		"/narration":     g.handleNarration,
+		"/players":       g.handlePlayers,
+		"/sheriff":       g.handleSheriff,
+		"/postgame":      g.handlePostGame,
+		stopGameEndpoint: g.handleStop,
+	}
+	if g.Mode == ServerMode {
+		handlers[ClientEndpoint] = g.handleClient
+	}
+	return handlers
+}
+
 type InitGameRequest struct {
-	VillagerCount int `json:"villagerCount"`
-	WerewolfCount int `json:"werewolfCount"`
-	ProphetCount  int `json:"prophetCount"`
-	WizardCount   int `json:"wizardCount"`
-	HunterCount   int `json:"hunterCount"`
-	MoronCount    int `json:"moronCount"`
-	GuardCount    int `json:"guardCount"`
-	WhiteWolfCount int `json:"whiteWolfCount"`
+	// Roles maps a role name, e.g. Villager or WhiteWolf, to its number of cards.
+	Roles map[string]int `json:"roles"`
+	// ExtraCards are the role names added to the deck for the roles taking
+	// extra cards: two for the Thief, who is shown the two cards left undealt
+	// on the first night.
+	ExtraCards []string `json:"extraCards,omitempty"`
+	// Alignments maps a role name to what the Prophet learns about its
+	// players, Werewolf or Good, overriding the side of the role, e.g. a Wolf
+	// King seen as Good.
+	Alignments map[string]string `json:"alignments,omitempty"`
+	// Seed of the deal, picked by the server when not given.
+	Seed *int64 `json:"seed,omitempty"`
+	// VictoryRule is sideKill (the default) or allKill.
+	VictoryRule string `json:"victoryRule,omitempty"`
+	// Sheriff adds the election of the sheriff on the first day. The vote of
+	// the sheriff counts for 1.5 and the sheriff chooses the speaking order.
+	Sheriff bool `json:"sheriff,omitempty"`
+	// PhaseTimeouts maps a phase (Thief, Cupid, Werewolf, Guard, Wizard, Prophet,
+	// Day, HunterShot, SheriffCandidacy, SheriffSpeech, SheriffVote, Badge,
+	// PKSpeech, PKVote, LastWords) to its deadline in seconds, and
+	// TimeoutActions to what happens when the deadline passes (skip or random).
+	PhaseTimeouts  map[string]int    `json:"phaseTimeouts,omitempty"`
+	TimeoutActions map[string]string `json:"timeoutActions,omitempty"`
+	// VoicePack is the narrator of the game, the default pack when not given.
+	VoicePack string `json:"voicePack,omitempty"`
+	// WizardRules are the house rules of the wizard, see WizardRules.
+	WizardRules WizardRules `json:"wizardRules"`
+	// GuardRules are the house rules of the guard, see GuardRules.
+	GuardRules GuardRules `json:"guardRules"`
+	// WolfVoteRule decides the kill from the votes of the werewolves: majority
+	// (the default), unanimous or lastChange.
+	WolfVoteRule string `json:"wolfVoteRule,omitempty"`
+	// TieRule settles a tie at the banishment vote: pk (the default), none or random.
+	TieRule string `json:"tieRule,omitempty"`
+	// Language of the narration text, en or zh, the language of the voice pack when not given.
+	Language string `json:"language,omitempty"`
 }
 
 type ActionRequest struct {
@@ -75,10 +139,13 @@ type ActionRequest struct {
 }
 
 type ActionResponse struct {
-	Successful  bool     `json:"successful"`
-	ActionCodes []int    `json:"actionCodes"`
-	ActionName  []string `json:"actionNames"`
-	Message     string   `json:"message"`
+	Successful       bool     `json:"successful"`
+	ActionCodes      []int    `json:"actionCodes"`
+	ActionName       []string `json:"actionNames"`
+	Message          string   `json:"message"`
+	RemainingSeconds int      `json:"remainingSeconds,omitempty"`
+	// TeamVotes are the current picks of the werewolves, shown to werewolves.
+	TeamVotes []TeamVote `json:"teamVotes,omitempty"`
 }
 
 type RegisterRequest struct {
@@ -87,6 +154,14 @@ type RegisterRequest struct {
 	Password string `json:"password"`
 }
 
+// VoteRequest is the vote of a player at the banishment, -1 to abstain.
+type VoteRequest struct {
+	Id       int    `json:"id"`
+	Password string `json:"password"`
+	Target   int    `json:"target"`
+}
+
+// DayEndRequest is the moderator banishing a player, overriding the vote.
 type DayEndRequest struct {
 	BanishId int `json:"banishId"`
 }
@@ -109,23 +184,64 @@ type LastNightResponse struct {
 }
 
 type StartGameResponse struct {
-	Message string `json:"message"`
+	Message    string `json:"message"`
+	Commitment string `json:"commitment"`
 }
 
 type StopGameResponse struct {
 	Message string `json:"message"`
 }
 
+type PlayerRole struct {
+	Id         int    `json:"id"`
+	PlayerName string `json:"playerName"`
+	RoleName   string `json:"roleName"`
+	// FinalRoleName is the role the player ended up with, if the Thief swapped it.
+	FinalRoleName string `json:"finalRoleName,omitempty"`
+}
+
+type PostGameResponse struct {
+	Code       int          `json:"code"`
+	Message    string       `json:"message,omitempty"`
+	Seed       int64        `json:"seed"`
+	Salt       string       `json:"salt"`
+	Commitment string       `json:"commitment"`
+	Victory    *Victory     `json:"victory,omitempty"`
+	Deal       []PlayerRole `json:"deal"`
+	// Lovers are the players linked by Cupid, if any.
+	Lovers []int `json:"lovers,omitempty"`
+	// ExtraCards are the cards left undealt for the Thief.
+	ExtraCards []string `json:"extraCards,omitempty"`
+}
+
+type VerifyResponse struct {
+	Valid   bool   `json:"valid"`
+	Message string `json:"message"`
+}
+
+type StateResponse struct {
+	Phase      int      `json:"phase"`
+	PhaseName  string   `json:"phaseName"`
+	Day        int      `json:"day"`
+	WaitingFor string   `json:"waitingFor"`
+	WaitingOn  []int    `json:"waitingOn"`
+	Victory    *Victory `json:"victory,omitempty"`
+	// RemainingSeconds is the time left to act in the phase, if it has a deadline.
+	RemainingSeconds int `json:"remainingSeconds,omitempty"`
+	// Announcements are the public news of the day, e.g. an explosion or a shot.
+	Announcements []string `json:"announcements,omitempty"`
+}
+
 func (g *GameServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Werewolf Server is healthy! Haoming is healthier!"))
 }
 
-func (g *GameServer) handleStop(w http.ResponseWriter, r *http.Request) {
+func (g *GameServer) handleStop(w http.ResponseWriter, r *http.Request, room *Room) {
 	if r.Method != "POST" {
 		g.writeClientError(w, http.StatusBadRequest, "Only POST is supported")
 		return
 	}
-	g.Controller = CreateController(g.Controller.gameMode)
+	room.reset()
 	res := StopGameResponse{
 		Message: "Game successfully stopped!",
 	}
@@ -137,18 +253,15 @@ func (g *GameServer) handleStop(w http.ResponseWriter, r *http.Request) {
 	w.Write(resBytes)
 }
 
-func (g *GameServer) handleClient(w http.ResponseWriter, r *http.Request) {
-	code := -1
+func (g *GameServer) handleClient(w http.ResponseWriter, r *http.Request, room *Room) {
+	var res *ClientResponse
 	select {
-	case code = <-g.Controller.clientChan:
+	case res = <-room.Controller().clientChan:
 
 	case <-time.After(serverTimeout):
 		w.WriteHeader(http.StatusGatewayTimeout)
 		return
 	}
-	res := ClientResponse{
-		TurnCode: code,
-	}
 	resBytes, err := json.Marshal(res)
 	if err != nil {
 		g.writeServerError(w, err.Error())
@@ -172,16 +285,16 @@ func (g *GameServer) handleHome(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (g *GameServer) handleLastNight(w http.ResponseWriter, r *http.Request) {
+func (g *GameServer) handleLastNight(w http.ResponseWriter, r *http.Request, room *Room) {
 	if r.Method != "GET" {
 		g.writeClientError(w, http.StatusBadRequest, "Only GET is supported")
 		return
 	}
-	if !g.Controller.isInitialized() {
+	if !room.Controller().isInitialized() {
 		g.writeClientError(w, http.StatusForbidden, "Game has not been initialized")
 		return
 	}
-	res := g.Controller.GetLastNightInfo()
+	res := room.Controller().GetLastNightInfo()
 	resBytes, err := json.Marshal(res)
 	if err != nil {
 		g.writeServerError(w, err.Error())
@@ -190,13 +303,131 @@ func (g *GameServer) handleLastNight(w http.ResponseWriter, r *http.Request) {
 	w.Write(resBytes)
 }
 
-func (g *GameServer) handleDayEnd(w http.ResponseWriter, r *http.Request) {
+// handleNarration returns the narrations after the sequence number given by since.
+func (g *GameServer) handleNarration(w http.ResponseWriter, r *http.Request, room *Room) {
+	if r.Method != "GET" {
+		g.writeClientError(w, http.StatusBadRequest, "Only GET is supported")
+		return
+	}
+	since := 0
+	if s := r.URL.Query().Get("since"); s != "" {
+		var err error
+		since, err = strconv.Atoi(s)
+		if err != nil {
+			g.writeClientError(w, http.StatusBadRequest, "Invalid since: "+s)
+			return
+		}
+	}
+	res := NarrationResponse{
+		Narrations: room.Controller().GetNarrations(since),
+	}
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
+
+// handlePlayers returns the public list of the players.
+func (g *GameServer) handlePlayers(w http.ResponseWriter, r *http.Request, room *Room) {
+	if r.Method != "GET" {
+		g.writeClientError(w, http.StatusBadRequest, "Only GET is supported")
+		return
+	}
+	res := PlayersResponse{
+		Players: room.Controller().GetPlayers(),
+	}
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
+
+func (g *GameServer) handleSheriff(w http.ResponseWriter, r *http.Request, room *Room) {
+	if r.Method != "GET" {
+		g.writeClientError(w, http.StatusBadRequest, "Only GET is supported")
+		return
+	}
+	res := room.Controller().GetSheriff()
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
+
+func (g *GameServer) handleState(w http.ResponseWriter, r *http.Request, room *Room) {
+	if r.Method != "GET" {
+		g.writeClientError(w, http.StatusBadRequest, "Only GET is supported")
+		return
+	}
+	res := room.Controller().GetState()
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
+
+func (g *GameServer) handlePostGame(w http.ResponseWriter, r *http.Request, room *Room) {
+	if r.Method != "GET" {
+		g.writeClientError(w, http.StatusBadRequest, "Only GET is supported")
+		return
+	}
+	if !room.Controller().isInitialized() {
+		g.writeClientError(w, http.StatusForbidden, "Game has not been initialized")
+		return
+	}
+	res := room.Controller().GetPostGameInfo()
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.WriteHeader(res.Code)
+	w.Write(resBytes)
+}
+
+func (g *GameServer) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		g.writeClientError(w, http.StatusBadRequest, "Only POST is supported")
+		return
+	}
+	defer r.Body.Close()
+	bodyBytes, err := ioutil.ReadAll(r.Body)
+	req := &PostGameResponse{}
+	err = json.Unmarshal(bodyBytes, req)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	res := VerifyResponse{
+		Valid:   req.Verify(),
+		Message: "The deal matches the commitment.",
+	}
+	if !res.Valid {
+		res.Message = "The deal does NOT match the commitment!"
+	}
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
+
+func (g *GameServer) handleDayEnd(w http.ResponseWriter, r *http.Request, room *Room) {
 	if r.Method != "POST" {
 		g.writeClientError(w, http.StatusBadRequest, "Only POST is supported")
 		return
 	}
 	defer r.Body.Close()
-	if !g.Controller.isInitialized() {
+	if !room.Controller().isInitialized() {
 		g.writeClientError(w, http.StatusForbidden, "Game has not been initialized")
 		return
 	}
@@ -209,14 +440,14 @@ func (g *GameServer) handleDayEnd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate request
-	valid, reason := rr.Validate(g.Controller)
+	valid, reason := rr.Validate(room.Controller())
 	if !valid {
 		g.writeClientError(w, http.StatusBadRequest, reason)
 		return
 	}
 
 	//  banish player
-	res := g.Controller.BanishPlayer(rr.BanishId)
+	res := room.Controller().BanishPlayer(rr.BanishId)
 	resBytes, err := json.Marshal(res)
 	if err != nil {
 		g.writeServerError(w, err.Error())
@@ -226,21 +457,56 @@ func (g *GameServer) handleDayEnd(w http.ResponseWriter, r *http.Request) {
 
 }
 
-func (g *GameServer) handleStart(w http.ResponseWriter, r *http.Request) {
+// handleVote shows the votes of the banishment on GET and casts the vote of
+// a player on POST.
+func (g *GameServer) handleVote(w http.ResponseWriter, r *http.Request, room *Room) {
+	var res interface{}
+	switch r.Method {
+	case "GET":
+		res = room.Controller().GetVotes()
+	case "POST":
+		defer r.Body.Close()
+		bodyBytes, err := ioutil.ReadAll(r.Body)
+		req := &VoteRequest{}
+		err = json.Unmarshal(bodyBytes, req)
+		if err != nil {
+			g.writeServerError(w, err.Error())
+			return
+		}
+		valid, reason := req.Validate(room.Controller())
+		if !valid {
+			g.writeClientError(w, http.StatusUnauthorized, reason)
+			return
+		}
+		res = room.Controller().Vote(req.Id, req.Target)
+	default:
+		g.writeClientError(w, http.StatusBadRequest, "Only GET and POST are supported")
+		return
+	}
+	resBytes, err := json.Marshal(res)
+	if err != nil {
+		g.writeServerError(w, err.Error())
+		return
+	}
+	w.Write(resBytes)
+}
+
+func (g *GameServer) handleStart(w http.ResponseWriter, r *http.Request, room *Room) {
 	if r.Method != "POST" {
 		g.writeClientError(w, http.StatusBadRequest, "Only POST is supported")
 		return
 	}
-	if !g.Controller.isInitialized() {
+	if !room.Controller().isInitialized() {
 		g.writeClientError(w, http.StatusForbidden, "Game has not been initialized")
 		return
 	}
-	if success, msg := g.Controller.StartGame(); !success {
+	if success, msg := room.Controller().StartGame(); !success {
 		g.writeClientError(w, http.StatusForbidden, msg)
 		return
 	}
 	res := StartGameResponse{
-		Message: "Game started",
+		Message:    "Game started",
+		Commitment: room.Controller().GetCommitment(),
 	}
 	// response
 	resBytes, err := json.Marshal(res)
@@ -250,14 +516,14 @@ func (g *GameServer) handleStart(w http.ResponseWriter, r *http.Request) {
 	w.Write(resBytes)
 }
 
-func (g *GameServer) handleRegister(w http.ResponseWriter, r *http.Request) {
+func (g *GameServer) handleRegister(w http.ResponseWriter, r *http.Request, room *Room) {
 	// parse request
 	if r.Method != "POST" {
 		g.writeClientError(w, http.StatusBadRequest, "Only POST is supported")
 		return
 	}
 	defer r.Body.Close()
-	if !g.Controller.isInitialized() {
+	if !room.Controller().isInitialized() {
 		g.writeClientError(w, http.StatusForbidden, "Game has not been initialized")
 		return
 	}
@@ -270,14 +536,14 @@ func (g *GameServer) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate request
-	valid, reason := rr.Validate(g.Controller.TotalCount)
+	valid, reason := rr.Validate(room.Controller().PlayerCount())
 	if !valid {
 		g.writeClientError(w, http.StatusBadRequest, reason)
 		return
 	}
 
 	// send response
-	res := g.Controller.Register(rr)
+	res := room.Controller().Register(rr)
 	w.WriteHeader(res.Code)
 	resBytes, err := json.Marshal(res)
 	if err != nil {
@@ -290,7 +556,7 @@ func (g *GameServer) handleRegister(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (g *GameServer) handleAction(w http.ResponseWriter, r *http.Request) {
+func (g *GameServer) handleAction(w http.ResponseWriter, r *http.Request, room *Room) {
 	// parse request
 	if r.Method != "POST" {
 		g.writeClientError(w, http.StatusBadRequest, "Only POST is supported")
@@ -305,14 +571,14 @@ func (g *GameServer) handleAction(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// validate request
-	valid, reason := req.Validate(g.Controller)
+	valid, reason := req.Validate(room.Controller())
 	if !valid {
 		g.writeClientError(w, http.StatusUnauthorized, reason)
 		return
 	}
 	//log.Println(string(bodyBytes))
 	// sendResponse
-	res := g.Controller.HandleAction(req.Id, req.ActionCode, req.Target)
+	res := room.Controller().HandleAction(req.Id, req.ActionCode, req.Target)
 	resBytes, err := json.Marshal(res)
 	if err != nil {
 		g.writeServerError(w, err.Error())
@@ -321,7 +587,7 @@ func (g *GameServer) handleAction(w http.ResponseWriter, r *http.Request) {
 	w.Write(resBytes)
 }
 
-func (g *GameServer) handleInit(w http.ResponseWriter, r *http.Request) {
+func (g *GameServer) handleInit(w http.ResponseWriter, r *http.Request, room *Room) {
 	if r.Method != "POST" {
 		g.writeClientError(w, http.StatusBadRequest, "Only POST is supported")
 		return
@@ -343,7 +609,7 @@ func (g *GameServer) handleInit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// initialize context
-	if !g.Controller.Initialize(sgr) {
+	if !room.Controller().Initialize(sgr) {
 		g.writeClientError(w, http.StatusForbidden, "Game already initialized!")
 		return
 	}
@@ -384,37 +650,69 @@ func (g *GameServer) writeClientError(w http.ResponseWriter, code int, message s
 func (s *InitGameRequest) Validate() (bool, string) {
 	valid := true
 	reason := []string{}
-	if s.VillagerCount <= 0 {
+	if invalid := validRoleCounts(s.Roles, s.ExtraCards); len(invalid) > 0 {
+		valid = false
+		reason = append(reason, invalid...)
+	}
+	if !validExtraCards(s.ExtraCards, s.Roles) {
+		valid = false
+		reason = append(reason, "ExtraCards")
+	}
+	if !validAlignments(s.Alignments) {
+		valid = false
+		reason = append(reason, "Alignments")
+	}
+	if _, ok := victoryRules[s.VictoryRule]; s.VictoryRule != "" && !ok {
 		valid = false
-		reason = append(reason, "VillagerCount")
+		reason = append(reason, "VictoryRule")
 	}
-	if s.WerewolfCount <= 0 {
+	if !validateTimeouts(s.PhaseTimeouts, s.TimeoutActions) {
 		valid = false
-		reason = append(reason, "WerewolfCount")
+		reason = append(reason, "PhaseTimeouts")
 	}
-	if s.ProphetCount < 0 || s.ProphetCount > 1 {
+	if _, ok := voicePacks[s.VoicePack]; s.VoicePack != "" && !ok {
 		valid = false
-		reason = append(reason, "ProphetCount")
+		reason = append(reason, "VoicePack")
 	}
-	if s.WizardCount < 0 || s.WizardCount > 1 {
+	if s.WizardRules.SelfSave != "" && !isInStringSlice(s.WizardRules.SelfSave, selfSaveRules) {
 		valid = false
-		reason = append(reason, "WizardCount")
+		reason = append(reason, "WizardRules")
 	}
-	if s.HunterCount < 0 || s.HunterCount > 1 {
+	if s.WolfVoteRule != "" && !isInStringSlice(s.WolfVoteRule, wolfVoteRules) {
 		valid = false
-		reason = append(reason, "HunterCount")
+		reason = append(reason, "WolfVoteRule")
 	}
-	if s.MoronCount < 0 || s.MoronCount > 1 {
+	if s.TieRule != "" && !isInStringSlice(s.TieRule, tieRules) {
 		valid = false
-		reason = append(reason, "MoronCount")
+		reason = append(reason, "TieRule")
 	}
-	if s.GuardCount < 0 || s.GuardCount > 1 {
+	if _, ok := narrationScripts[s.Language]; s.Language != "" && !ok {
 		valid = false
-		reason = append(reason, "GuardCount")
+		reason = append(reason, "Language")
 	}
 	return valid, strings.Join(reason, " && ")
 }
 
+// validExtraCards checks that there are as many extra cards as the roles of
+// the game take, e.g. two for the Thief, of roles which may be left undealt.
+func validExtraCards(cards []string, counts map[string]int) bool {
+	want := 0
+	for name, count := range counts {
+		if spec, ok := roleRegistry[name]; ok {
+			want += count * spec.extraCards
+		}
+	}
+	if len(cards) != want {
+		return false
+	}
+	for _, name := range cards {
+		if spec, ok := roleRegistry[name]; !ok || spec.dealt {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *RegisterRequest) Validate(totalNum int) (bool, string) {
 	if r.Id < 0 || r.Id >= totalNum {
 		return false, "Invalid id"
@@ -423,20 +721,35 @@ func (r *RegisterRequest) Validate(totalNum int) (bool, string) {
 }
 
 func (r *ActionRequest) Validate(c *Controller) (bool, string) {
-	if r.Id < 0 || r.Id >= c.TotalCount {
+	totalCount := c.PlayerCount()
+	if r.Id < 0 || r.Id >= totalCount {
+		return false, "Invalid id"
+	}
+	if !c.checkPassword(r.Id, r.Password) {
+		return false, "Wrong Password"
+	}
+	if r.Target < 0 || r.Target >= totalCount {
+		return false, "Invalid id"
+	}
+	return true, ""
+}
+
+func (r *VoteRequest) Validate(c *Controller) (bool, string) {
+	totalCount := c.PlayerCount()
+	if r.Id < 0 || r.Id >= totalCount {
 		return false, "Invalid id"
 	}
-	if c.Passwords[r.Id] != r.Password {
+	if !c.checkPassword(r.Id, r.Password) {
 		return false, "Wrong Password"
 	}
-	if r.Target < 0 || r.Target >= c.TotalCount {
+	if r.Target < -1 || r.Target >= totalCount {
 		return false, "Invalid id"
 	}
 	return true, ""
 }
 
 func (r *DayEndRequest) Validate(c *Controller) (bool, string) {
-	if r.BanishId < 0 || r.BanishId >= c.TotalCount {
+	if r.BanishId < 0 || r.BanishId >= c.PlayerCount() {
 		return false, "Invalid id"
 	}
 	return true, ""
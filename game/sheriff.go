@@ -0,0 +1,395 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sheriffVoteWeight is what the vote of the sheriff counts for at the banishment.
+const sheriffVoteWeight = 1.5
+
+// The speaking orders the sheriff may choose, given as target of SkillSpeakingOrder.
+const (
+	Clockwise = iota
+	CounterClockwise
+)
+
+// sheriffElection is the election of the sheriff on the first day.
+type sheriffElection struct {
+	// running holds the decision of every player who decided whether to run.
+	running map[int]bool
+	// candidates are the players who ran, in speaking order.
+	candidates []int
+	withdrawn  []int
+	speaker    int
+	// runoff holds the candidates tied at the first vote, voted on again.
+	runoff []int
+	votes  map[int]int
+}
+
+type SheriffResponse struct {
+	// Sheriff is the id of the sheriff, -1 if there is none.
+	Sheriff    int   `json:"sheriff"`
+	Candidates []int `json:"candidates"`
+	Withdrawn  []int `json:"withdrawn"`
+	// Speaker is the candidate giving their speech, -1 if none.
+	Speaker int `json:"speaker"`
+	// Votes are the votes of the election, public once it is over.
+	Votes []TeamVote `json:"votes"`
+	// SpeakingOrder is the order the sheriff chose for the speeches of the day.
+	SpeakingOrder []int `json:"speakingOrder"`
+}
+
+// dayStart returns the phase the day starts with: the election of the
+// sheriff on the first day, if the game has a sheriff.
+func (c *Controller) dayStart() int {
+	if c.sheriffRule && !c.electionHeld {
+		return TurnSheriffCandidacy
+	}
+	return TurnDay
+}
+
+// awaitSheriffCandidacy waits for every living player to run for sheriff or
+// not, then orders the candidates from a random seat.
+func (c *Controller) awaitSheriffCandidacy() int {
+	c.electionHeld = true
+	if c.GameIsEnd() {
+		return TurnDay
+	}
+	e := &sheriffElection{running: map[int]bool{}, speaker: -1}
+	c.election = e
+	c.machine.wait("Candidacy", c.undecided())
+	if _, ok := c.awaitInput(TurnSheriffCandidacy); !ok {
+		return TurnDay
+	}
+	start := c.rng.Intn(c.TotalCount)
+	for i := 0; i < c.TotalCount; i++ {
+		id := (start + i) % c.TotalCount
+		if e.running[id] {
+			e.candidates = append(e.candidates, id)
+		}
+	}
+	if len(e.candidates) < 2 {
+		return c.electSheriff(e.candidates)
+	}
+	c.announce("Candidates for sheriff, in speaking order: " + joinIds(e.candidates) + ".")
+	return TurnSheriffSpeech
+}
+
+// undecided returns the living players who haven't decided whether to run.
+func (c *Controller) undecided() []int {
+	ids := []int{}
+	for i, r := range c.Roles {
+		if _, ok := c.election.running[i]; !ok && !r.IsDead() {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+// awaitSheriffSpeech gives the floor to every candidate in turn.
+func (c *Controller) awaitSheriffSpeech() int {
+	e := c.election
+	for _, id := range e.candidates {
+		if isInSlice(id, e.withdrawn) {
+			continue
+		}
+		e.speaker = id
+		c.machine.wait("Speech", []int{id})
+		if _, ok := c.awaitInput(TurnSheriffSpeech); !ok {
+			return TurnDay
+		}
+	}
+	e.speaker = -1
+	remaining := e.remaining()
+	if len(remaining) < 2 || len(c.sheriffVoters()) == 0 {
+		return c.electSheriff(remaining)
+	}
+	return TurnSheriffVote
+}
+
+// remaining returns the candidates who haven't withdrawn.
+func (e *sheriffElection) remaining() []int {
+	ids := []int{}
+	for _, id := range e.candidates {
+		if !isInSlice(id, e.withdrawn) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sheriffVoters returns the players voting for the sheriff: the living
+// players with a vote who didn't run.
+func (c *Controller) sheriffVoters() []int {
+	ids := []int{}
+	for i, r := range c.Roles {
+		if canVote(r) && !isInSlice(i, c.election.candidates) {
+			ids = append(ids, i)
+		}
+	}
+	return ids
+}
+
+// pendingVoters returns the voters who haven't voted yet.
+func (c *Controller) pendingVoters() []int {
+	ids := []int{}
+	for _, id := range c.sheriffVoters() {
+		if _, ok := c.election.votes[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// awaitSheriffVote waits for the votes and elects the candidate with the
+// most. Tied candidates go to a runoff once, after which nobody is elected.
+func (c *Controller) awaitSheriffVote() int {
+	e := c.election
+	e.votes = map[int]int{}
+	c.machine.wait("SheriffVote", c.pendingVoters())
+	if _, ok := c.awaitInput(TurnSheriffVote); !ok {
+		return TurnDay
+	}
+	c.announce("Votes for sheriff: " + c.sheriffVotesMessage() + ".")
+	best := c.leadingCandidates()
+	if len(best) > 1 && e.runoff == nil {
+		e.runoff = best
+		c.announce("Players " + joinIds(best) + " are tied and go to a runoff.")
+		return TurnSheriffVote
+	}
+	if len(best) > 1 {
+		best = nil
+	}
+	return c.electSheriff(best)
+}
+
+// ballot returns the candidates who may be voted for.
+func (e *sheriffElection) ballot() []int {
+	if e.runoff != nil {
+		return e.runoff
+	}
+	return e.remaining()
+}
+
+// leadingCandidates returns the candidates with the most votes.
+func (c *Controller) leadingCandidates() []int {
+	counts := map[int]int{}
+	for _, target := range c.election.votes {
+		if target >= 0 {
+			counts[target]++
+		}
+	}
+	best, most := []int{}, 0
+	for _, id := range c.election.ballot() {
+		switch {
+		case counts[id] > most:
+			best, most = []int{id}, counts[id]
+		case counts[id] == most:
+			best = append(best, id)
+		}
+	}
+	return best
+}
+
+func (c *Controller) sheriffVotesMessage() string {
+	votes := []string{}
+	for _, v := range c.sheriffVotes() {
+		if v.Target < 0 {
+			votes = append(votes, fmt.Sprintf("%d: blank", v.Id+1))
+		} else {
+			votes = append(votes, fmt.Sprintf("%d -> %d", v.Id+1, v.Target+1))
+		}
+	}
+	if len(votes) == 0 {
+		return "none"
+	}
+	return strings.Join(votes, ", ")
+}
+
+func (c *Controller) sheriffVotes() []TeamVote {
+	votes := []TeamVote{}
+	if c.election == nil {
+		return votes
+	}
+	for id, target := range c.election.votes {
+		votes = append(votes, TeamVote{Id: id, Target: target})
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i].Id < votes[j].Id })
+	return votes
+}
+
+// electSheriff ends the election: the only player given is the sheriff,
+// otherwise there is none.
+func (c *Controller) electSheriff(ids []int) int {
+	if len(ids) != 1 {
+		c.announce("No sheriff was elected.")
+		return TurnDay
+	}
+	c.sheriff = ids[0]
+	c.announce(fmt.Sprintf("Player %d is elected sheriff.", c.sheriff+1))
+	return TurnDay
+}
+
+// awaitBadge waits for the dead sheriff to pass the badge on or tear it up.
+func (c *Controller) awaitBadge() int {
+	sheriffId := c.sheriff
+	c.machine.wait("Badge", []int{sheriffId})
+	targetId, ok := c.awaitInput(TurnBadge)
+	if !ok {
+		return c.badgeNext
+	}
+	if targetId >= 0 {
+		c.sheriff = targetId
+		c.announce(fmt.Sprintf("The sheriff, player %d, passed the badge to player %d.", sheriffId+1, targetId+1))
+	} else {
+		c.sheriff = -1
+		c.announce(fmt.Sprintf("The sheriff, player %d, tore up the badge.", sheriffId+1))
+	}
+	return c.afterDeaths(c.badgeNext)
+}
+
+// voteWeight returns what the vote of the player counts for at the banishment.
+func (c *Controller) voteWeight(id int) float64 {
+	switch {
+	case !canVote(c.Roles[id]):
+		return 0
+	case id == c.sheriff:
+		return sheriffVoteWeight
+	}
+	return 1
+}
+
+// setSpeakingOrder orders the speeches of the day from the player next to
+// the sheriff, in the given direction, the sheriff speaking last.
+func (c *Controller) setSpeakingOrder(direction int) {
+	step := 1
+	if direction == CounterClockwise {
+		step = c.TotalCount - 1
+	}
+	c.speakingOrder = []int{}
+	for i, id := 0, c.sheriff; i < c.TotalCount; i++ {
+		id = (id + step) % c.TotalCount
+		if !c.Roles[id].IsDead() {
+			c.speakingOrder = append(c.speakingOrder, id)
+		}
+	}
+}
+
+// sheriffActionCodes returns the actions of the election and of the badge
+// the player may take now, whatever their role.
+func (c *Controller) sheriffActionCodes(id int) []int {
+	phase := c.machine.current()
+	e := c.election
+	switch {
+	case phase == TurnSheriffCandidacy && !c.Roles[id].IsDead():
+		return []int{SkillRun, SkillWithdraw}
+	case phase == TurnSheriffSpeech && isInSlice(id, e.remaining()):
+		if id == e.speaker {
+			return []int{SkillEndSpeech, SkillWithdraw}
+		}
+		return []int{SkillWithdraw}
+	case phase == TurnSheriffVote && isInSlice(id, c.sheriffVoters()):
+		return []int{SkillVote, SkillDontUse}
+	case phase == TurnBadge && id == c.sheriff:
+		return []int{SkillPassBadge, SkillDontUse}
+	case phase == TurnDay && id == c.sheriff && !c.Roles[id].IsDead() && c.speakingOrder == nil:
+		return []int{SkillSpeakingOrder}
+	}
+	return nil
+}
+
+// sheriffAct takes an action of the election or of the badge.
+func (c *Controller) sheriffAct(id int, action int, targetId int) (bool, string) {
+	phase := c.machine.current()
+	if !c.machine.awaits(phase) || !isInSlice(action, c.sheriffActionCodes(id)) {
+		return false, "You can't do that now!"
+	}
+	e := c.election
+	switch action {
+	case SkillRun, SkillWithdraw:
+		if phase == TurnSheriffCandidacy {
+			e.running[id] = action == SkillRun
+			if undecided := c.undecided(); len(undecided) > 0 {
+				c.machine.wait("Candidacy", undecided)
+			} else {
+				c.input(TurnSheriffCandidacy, 0)
+			}
+			if action == SkillRun {
+				return true, "You run for sheriff."
+			}
+			return true, "You don't run for sheriff."
+		}
+		e.withdrawn = append(e.withdrawn, id)
+		c.announce(fmt.Sprintf("Player %d withdraws from the election.", id+1))
+		if id == e.speaker {
+			c.input(TurnSheriffSpeech, id)
+		}
+		return true, "You withdrew from the election."
+	case SkillEndSpeech:
+		c.input(TurnSheriffSpeech, id)
+		return true, "Your speech is over."
+	case SkillVote, SkillDontUse:
+		if phase == TurnBadge {
+			c.input(TurnBadge, -1)
+			return true, "You tore up the badge."
+		}
+		if action == SkillDontUse {
+			targetId = -1
+		} else if !isInSlice(targetId, e.ballot()) {
+			return false, "Target is not a candidate!"
+		}
+		e.votes[id] = targetId
+		if pending := c.pendingVoters(); len(pending) > 0 {
+			c.machine.wait("SheriffVote", pending)
+		} else {
+			c.input(TurnSheriffVote, 0)
+		}
+		return true, "Vote Succeeded!"
+	case SkillPassBadge:
+		if targetId == id || c.Roles[targetId].IsDead() {
+			return false, "You can only pass the badge to a living player!"
+		}
+		c.input(TurnBadge, targetId)
+		return true, "You passed the badge."
+	case SkillSpeakingOrder:
+		if targetId != Clockwise && targetId != CounterClockwise {
+			return false, "Choose clockwise (0) or counterclockwise (1)!"
+		}
+		c.setSpeakingOrder(targetId)
+		c.announce("The sheriff chose the speaking order: " + joinIds(c.speakingOrder) + ".")
+		return true, "Speaking order set."
+	}
+	return false, "You're not able to use this skill!"
+}
+
+func (c *Controller) sheriffInfo() *SheriffResponse {
+	res := &SheriffResponse{
+		Sheriff:       c.sheriff,
+		Candidates:    []int{},
+		Withdrawn:     []int{},
+		Speaker:       -1,
+		Votes:         []TeamVote{},
+		SpeakingOrder: c.speakingOrder,
+	}
+	if e := c.election; e != nil {
+		res.Candidates = e.candidates
+		res.Withdrawn = e.withdrawn
+		res.Speaker = e.speaker
+		if c.machine.current() != TurnSheriffVote {
+			res.Votes = c.sheriffVotes()
+		}
+	}
+	return res
+}
+
+// joinIds lists the seats of the players, counting from 1.
+func joinIds(ids []int) string {
+	seats := make([]string, len(ids))
+	for i, id := range ids {
+		seats[i] = strconv.Itoa(id + 1)
+	}
+	return strings.Join(seats, ", ")
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// shooter is a role which may take a player down when it dies.
+type shooter interface {
+	Role
+	canShoot() bool
+}
+
+// triggerShot queues the shot of a player who just died. The shots are taken
+// once the deaths of the night or of the banishment are settled.
+func (c *Controller) triggerShot(id int) {
+	c.shooters = append(c.shooters, id)
+}
+
+// triggerKingShot queues the shot of a Wolf King who was banished or shot,
+// the only deaths letting the Wolf King take a player down.
+func (c *Controller) triggerKingShot(id int) {
+	if _, ok := c.Roles[id].(*WolfKing); ok {
+		c.triggerShot(id)
+	}
+}
+
+// shotAct fires at the target, or passes, for the shooter being waited on.
+func (c *Controller) shotAct(id int, action int, targetId int) (bool, string) {
+	if !c.machine.awaits(TurnHunterShot) || c.shooting != id {
+		return false, "Not your turn!"
+	}
+	if action == SkillDontUse {
+		c.input(TurnHunterShot, -1)
+		return true, "You didn't fire."
+	}
+	if action != SkillFire {
+		return false, "You're not able to use this skill!"
+	}
+	if targetId == id {
+		return false, "You can't fire yourself!"
+	}
+
+	// fire somebody
+	if c.Roles[targetId].IsDead() {
+		return false, "Target is already dead!"
+	}
+	c.input(TurnHunterShot, targetId)
+	return true, "Fire Succeeded!"
+}
+
+// afterDeaths returns the phase played once the deaths are settled: the badge
+// of a dead sheriff, then the shot of a dead shooter if there is one,
+// otherwise next. Once the game is won nobody is waited on anymore.
+func (c *Controller) afterDeaths(next int) int {
+	if c.GameIsEnd() {
+		c.shooters = nil
+		return next
+	}
+	if c.sheriff >= 0 && c.Roles[c.sheriff].IsDead() {
+		c.badgeNext = next
+		return TurnBadge
+	}
+	shooters := []int{}
+	for _, id := range c.shooters {
+		if s, ok := c.Roles[id].(shooter); ok && s.canShoot() {
+			shooters = append(shooters, id)
+		}
+	}
+	c.shooters = shooters
+	if len(c.shooters) == 0 {
+		return next
+	}
+	c.shotNext = next
+	return TurnHunterShot
+}
+
+// awaitHunterShot waits for the first queued shooter to shoot a player or pass,
+// then checks whether the shot ended the game.
+func (c *Controller) awaitHunterShot() int {
+	c.shooting = c.shooters[0]
+	c.shooters = c.shooters[1:]
+	c.machine.wait(turnName[TurnHunterShot], []int{c.shooting})
+	targetId, ok := c.awaitInput(TurnHunterShot)
+	shooterId := c.shooting
+	c.shooting = -1
+	if ok && targetId >= 0 {
+		c.kill(targetId, false)
+		c.triggerKingShot(targetId)
+		log.Printf("Player id=%d was shot by player id=%d", targetId+1, shooterId+1)
+		// shots taken before the vote of the day opened are news of the night
+		if c.banishVote == nil {
+			c.lastNight = append(c.lastNight, strconv.Itoa(targetId+1))
+		} else {
+			c.announce(fmt.Sprintf("Player %d was shot by player %d.", targetId+1, shooterId+1))
+		}
+	}
+	if c.GameIsEnd() {
+		return TurnGameOver
+	}
+	return c.afterDeaths(c.shotNext)
+}
@@ -0,0 +1,29 @@
+package game
+
+import "testing"
+
+// TestNoShotOnceWon checks that the game is over at once when the death of
+// the hunter ends it, without waiting for the shot.
+func TestNoShotOnceWon(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{Roles: map[string]int{"Villager": 2, "Werewolf": 1, "Hunter": 1}})
+	play(t, c, TurnWerewolf, seatsOf(c, "Werewolf")[0], SkillKill, seatsOf(c, "Hunter")[0])
+	awaitPhase(t, c, TurnGameOver)
+	if victory := c.GetState().Victory; victory == nil || victory.Faction != FactionWerewolf {
+		t.Errorf("%+v, want the werewolves to win", victory)
+	}
+}
+
+// TestShot checks that the hunter killed at night shoots before the day.
+func TestShot(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{Roles: map[string]int{"Villager": 3, "Werewolf": 2, "Hunter": 1, "Prophet": 1}})
+	wolves, hunter := seatsOf(c, "Werewolf"), seatsOf(c, "Hunter")[0]
+	for _, id := range wolves {
+		play(t, c, TurnWerewolf, id, SkillKill, hunter)
+	}
+	play(t, c, TurnProphet, seatsOf(c, "Prophet")[0], SkillVerifyRole, wolves[0])
+	play(t, c, TurnHunterShot, hunter, SkillFire, wolves[0])
+	awaitPhase(t, c, TurnDay)
+	if dead, _ := c.query(func() interface{} { return c.Roles[wolves[0]].IsDead() }).(bool); !dead {
+		t.Error("The werewolf shot by the hunter is alive")
+	}
+}
@@ -0,0 +1,96 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+type Thief struct {
+	player
+}
+
+func CreateThief(id int, c *Controller) *Thief {
+	return &Thief{player: newPlayer(id, "Thief", c)}
+}
+
+func init() {
+	registerRole(&roleSpec{
+		name:       "Thief",
+		faction:    FactionVillager,
+		nightTurns: []int{TurnThief},
+		abilities:  []int{SkillSwap, SkillDontUse},
+		max:        1,
+		dealt:      true,
+		extraCards: 2,
+		create:     func(id int, c *Controller) Role { return CreateThief(id, c) },
+	})
+}
+
+func (v *Thief) GetActionCode() (bool, []int) {
+	if atomic.LoadInt32(v.controller.phase) != TurnThief || v.dead {
+		return false, nil
+	}
+	if v.controller.thiefMustSwap() {
+		return true, []int{SkillSwap}
+	}
+	return true, []int{SkillSwap, SkillDontUse}
+}
+
+// Act swaps the Thief with the extra card given as target, 0 for the first
+// card and 1 for the second one. The Thief may keep their card unless both
+// extra cards are werewolves.
+func (v *Thief) Act(action int, targetId int) (bool, string) {
+	if !v.controller.machine.awaits(TurnThief) || v.dead {
+		return false, "Not your turn!"
+	}
+	switch action {
+	case SkillSwap:
+		if targetId < 0 || targetId >= len(v.controller.extraCards) {
+			return false, "There is no such card!"
+		}
+		role := v.controller.swapRole(v.id, targetId)
+		v.controller.input(TurnThief, targetId)
+		return true, fmt.Sprintf("Swap Succeeded! You are now the %s.", role.GetRoleName())
+	case SkillDontUse:
+		if v.controller.thiefMustSwap() {
+			return false, "Both extra cards are werewolves, you must take one!"
+		}
+		v.controller.input(TurnThief, -1)
+		return true, "You stay the Thief."
+	}
+	return false, "You're not able to use this skill!"
+}
+
+// cardsMessage shows the Thief the extra cards.
+func (c *Controller) cardsMessage() string {
+	cards := make([]string, len(c.extraCards))
+	for i, name := range c.extraCards {
+		cards[i] = fmt.Sprintf("%d. %s", i+1, name)
+	}
+	return "The extra cards are: " + strings.Join(cards, ", ") + "."
+}
+
+// thiefMustSwap reports whether all the extra cards are werewolves, in which
+// case the Thief has to take one of them.
+func (c *Controller) thiefMustSwap() bool {
+	for _, name := range c.extraCards {
+		if roleRegistry[name].faction != FactionWerewolf {
+			return false
+		}
+	}
+	return len(c.extraCards) > 0
+}
+
+// swapRole gives the player the extra card and puts their card aside instead.
+// The player plays the new role for the rest of the game.
+func (c *Controller) swapRole(id int, card int) Role {
+	old := c.Roles[id]
+	role := roleRegistry[c.extraCards[card]].create(id, c)
+	role.Register(old.GetPlayerName())
+	c.extraCards[card] = old.GetRoleName()
+	c.Roles[id] = role
+	c.RoleCounts[old.GetRoleName()]--
+	c.RoleCounts[role.GetRoleName()]++
+	return role
+}
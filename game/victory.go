@@ -0,0 +1,131 @@
+package game
+
+import "fmt"
+
+const (
+	FactionWerewolf = "Werewolf"
+	FactionVillager = "Villager"
+	FactionGod      = "God"
+	// FactionGood is the side of villagers and gods together.
+	FactionGood = "Good"
+	// FactionLovers is a werewolf and a good player in love, playing against everybody else.
+	FactionLovers = "Lovers"
+	// FactionThirdParty is a role playing on its own, against both sides.
+	FactionThirdParty = "ThirdParty"
+)
+
+// The alignments a player may be seen as by information roles.
+const (
+	AlignmentWerewolf = "Werewolf"
+	AlignmentGood     = "Good"
+)
+
+const (
+	SideKillRule = "sideKill"
+	AllKillRule  = "allKill"
+)
+
+// Victory tells which faction won the game and why.
+type Victory struct {
+	Faction string `json:"faction"`
+	Reason  string `json:"reason"`
+}
+
+// VictoryRule decides whether the game is over. Check returns nil while the
+// game goes on.
+type VictoryRule interface {
+	Check(c *Controller) *Victory
+}
+
+// VictoryRuleFunc lets a plain function, e.g. the win of a third faction, be used as a VictoryRule.
+type VictoryRuleFunc func(c *Controller) *Victory
+
+func (f VictoryRuleFunc) Check(c *Controller) *Victory {
+	return f(c)
+}
+
+var victoryRules = map[string]VictoryRule{
+	SideKillRule: &sideKill{},
+	AllKillRule:  &allKill{},
+}
+
+// headcount is the number of players of every faction, alive and in total.
+type headcount struct {
+	alive map[string]int
+	total map[string]int
+}
+
+func countFactions(c *Controller) *headcount {
+	h := &headcount{
+		alive: map[string]int{},
+		total: map[string]int{},
+	}
+	for i, role := range c.Roles {
+		faction := role.GetFaction()
+		if c.mixedCouple() && c.loverOf(i) >= 0 {
+			faction = FactionLovers
+		}
+		h.total[faction]++
+		if !role.IsDead() {
+			h.alive[faction]++
+		}
+	}
+	return h
+}
+
+// wiped reports whether a faction that was in the game has no player alive.
+func (h *headcount) wiped(faction string) bool {
+	return h.total[faction] > 0 && h.alive[faction] == 0
+}
+
+// thirdFactionAlive reports whether a player of a faction other than the
+// werewolves and the good side, e.g. the lovers, is alive. Neither side can
+// win before the third faction is gone.
+func (h *headcount) thirdFactionAlive() bool {
+	for faction, alive := range h.alive {
+		switch faction {
+		case FactionWerewolf, FactionVillager, FactionGod:
+		default:
+			if alive > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sideKill: the werewolves win by killing all villagers or all gods.
+type sideKill struct{}
+
+func (r *sideKill) Check(c *Controller) *Victory {
+	h := countFactions(c)
+	if h.thirdFactionAlive() {
+		return nil
+	}
+	if h.wiped(FactionWerewolf) {
+		return &Victory{Faction: FactionGood, Reason: "All werewolves are dead."}
+	}
+	for _, faction := range []string{FactionVillager, FactionGod} {
+		if h.wiped(faction) {
+			return &Victory{Faction: FactionWerewolf, Reason: fmt.Sprintf("All %ss are dead.", faction)}
+		}
+	}
+	return nil
+}
+
+// allKill: the werewolves win by killing all villagers and all gods.
+type allKill struct{}
+
+func (r *allKill) Check(c *Controller) *Victory {
+	h := countFactions(c)
+	if h.thirdFactionAlive() {
+		return nil
+	}
+	if h.wiped(FactionWerewolf) {
+		return &Victory{Faction: FactionGood, Reason: "All werewolves are dead."}
+	}
+	if h.alive[FactionVillager]+h.alive[FactionGod] == 0 {
+		return &Victory{Faction: FactionWerewolf, Reason: "All villagers and gods are dead."}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package game
+
+import "testing"
+
+// dealtGame deals the roles of the request to a controller whose game loop
+// isn't running, for the rules to be checked on the seats directly.
+func dealtGame(sgr *InitGameRequest) *Controller {
+	seed := int64(1)
+	sgr.Seed = &seed
+	c := &Controller{phase: new(int32)}
+	c.machine = createPhaseMachine(c.phase)
+	c.machine.day = 1
+	c.setup(sgr)
+	return c
+}
+
+// seatOf returns the first seat dealt the role.
+func seatOf(c *Controller, roleName string) int {
+	for i, r := range c.Roles {
+		if r.GetRoleName() == roleName {
+			return i
+		}
+	}
+	return -1
+}
+
+// killRoles kills a living player of every role name given.
+func killRoles(c *Controller, names ...string) {
+	for _, name := range names {
+		for _, r := range c.Roles {
+			if r.GetRoleName() == name && !r.IsDead() {
+				r.Die(false)
+				break
+			}
+		}
+	}
+}
+
+func TestVictoryRules(t *testing.T) {
+	roles := map[string]int{"Villager": 2, "Werewolf": 2, "Prophet": 1, "Hunter": 1}
+	tests := []struct {
+		name    string
+		rule    string
+		dead    []string
+		faction string
+	}{
+		{"nobody dead", SideKillRule, nil, ""},
+		{"villagers dead", SideKillRule, []string{"Villager", "Villager"}, FactionWerewolf},
+		{"gods dead", SideKillRule, []string{"Prophet", "Hunter"}, FactionWerewolf},
+		{"one of each side dead", SideKillRule, []string{"Villager", "Prophet"}, ""},
+		{"werewolves dead", SideKillRule, []string{"Werewolf", "Werewolf"}, FactionGood},
+		{"villagers dead, all kill", AllKillRule, []string{"Villager", "Villager"}, ""},
+		{"gods dead, all kill", AllKillRule, []string{"Prophet", "Hunter"}, ""},
+		{"good side dead, all kill", AllKillRule, []string{"Villager", "Villager", "Prophet", "Hunter"}, FactionWerewolf},
+		{"werewolves dead, all kill", AllKillRule, []string{"Werewolf", "Werewolf"}, FactionGood},
+	}
+	for _, test := range tests {
+		c := dealtGame(&InitGameRequest{Roles: roles, VictoryRule: test.rule})
+		killRoles(c, test.dead...)
+		victory := victoryRules[test.rule].Check(c)
+		switch {
+		case test.faction == "" && victory != nil:
+			t.Errorf("%s: %s wins, want the game to go on", test.name, victory.Faction)
+		case test.faction != "" && (victory == nil || victory.Faction != test.faction):
+			t.Errorf("%s: %+v, want %s to win", test.name, victory, test.faction)
+		}
+	}
+}
+
+// TestLoversVictory checks that a werewolf and a villager in love keep both
+// sides from winning, and win once they are the last survivors.
+func TestLoversVictory(t *testing.T) {
+	c := dealtGame(&InitGameRequest{Roles: map[string]int{"Villager": 2, "Werewolf": 2, "Cupid": 1}})
+	wolf, villager := seatOf(c, "Werewolf"), seatOf(c, "Villager")
+	c.link(wolf, villager)
+	for i, r := range c.Roles {
+		if i != wolf && i != villager && r.GetRoleName() != "Cupid" {
+			r.Die(false)
+		}
+	}
+	if c.GameIsEnd() {
+		t.Fatalf("%+v with the lovers and Cupid alive, want the game to go on", c.victory)
+	}
+	killRoles(c, "Cupid")
+	if !c.GameIsEnd() || c.victory.Faction != FactionLovers {
+		t.Errorf("%+v, want the lovers to win", c.victory)
+	}
+}
@@ -1,62 +1,20 @@
 package game
 
-import (
-	"sync"
-)
-
 type Villager struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
+	player
 }
 
 func CreateVillager(id int, c *Controller) *Villager {
-	return &Villager{
-		id:         id,
-		roleName:   "Villager",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
-}
-
-func (v *Villager) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.VillagerCount--
-}
-
-func (v *Villager) IsDead() bool {
-	return v.dead
-}
-
-func (v *Villager) GetRoleName() string {
-	return v.roleName
-}
-
-func (v *Villager) GetPlayerName() string {
-	return v.playerName
-}
-
-func (v *Villager) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
-	}
-	v.playerName = name
-	v.registered = true
-	return true
+	return &Villager{player: newPlayer(id, "Villager", c)}
 }
 
-func (v *Villager) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+func init() {
+	registerRole(&roleSpec{
+		name:    "Villager",
+		faction: FactionVillager,
+		min:     1,
+		create:  func(id int, c *Controller) Role { return CreateVillager(id, c) },
+	})
 }
 
 func (v *Villager) GetActionCode() (bool, []int) {
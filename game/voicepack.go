@@ -0,0 +1,194 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// VoicePacksDir holds the voice packs besides the default one, one directory per pack.
+var VoicePacksDir = "./voices"
+
+const (
+	DefaultVoicePack  = "default"
+	voicePackManifest = "voicepack.json"
+)
+
+const (
+	CueEnter = "enter"
+	CueLeave = "leave"
+)
+
+// VoicePack is a narrator: a directory of clips and a manifest, voicepack.json,
+// telling which clips are played when.
+type VoicePack struct {
+	Language string `json:"language,omitempty"`
+	// Intro is played when the program starts.
+	Intro []string `json:"intro,omitempty"`
+	// Phases maps a phase name, e.g. Werewolf or HurryUp, to the clips played
+	// on entering and leaving it.
+	Phases map[string]*PhaseClips `json:"phases"`
+	name   string
+	dir    string
+}
+
+type PhaseClips struct {
+	Enter []string `json:"enter,omitempty"`
+	Leave []string `json:"leave,omitempty"`
+}
+
+// voicePacks are the packs loaded at startup, by name.
+var voicePacks = map[string]*VoicePack{}
+
+// LoadVoicePacks loads the default pack from defaultPack, e.g. the clips
+// embedded in the binary, and every pack found in dir. A pack whose manifest
+// is broken or refers to missing clips is left out; only a broken default
+// pack is an error.
+func LoadVoicePacks(defaultPack fs.FS, dir string) error {
+	pack, err := readVoicePack(defaultPack)
+	if err != nil {
+		return fmt.Errorf("default voice pack: %s", err.Error())
+	}
+	pack.name = DefaultVoicePack
+	if pack.dir, err = extractVoicePack(defaultPack, pack); err != nil {
+		return fmt.Errorf("default voice pack: %s", err.Error())
+	}
+	voicePacks[DefaultVoicePack] = pack
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, info := range infos {
+		if !info.IsDir() || info.Name() == DefaultVoicePack {
+			continue
+		}
+		packDir := filepath.Join(dir, info.Name())
+		pack, err := readVoicePack(os.DirFS(packDir))
+		if err != nil {
+			log.Printf("Skipping voice pack %s: %s", info.Name(), err.Error())
+			continue
+		}
+		pack.name = info.Name()
+		pack.dir = packDir
+		voicePacks[pack.name] = pack
+		log.Printf("Voice pack %s loaded", pack.name)
+	}
+	return nil
+}
+
+// readVoicePack reads the manifest of a pack and checks it against the clips of the pack.
+func readVoicePack(fsys fs.FS) (*VoicePack, error) {
+	manifestBytes, err := fs.ReadFile(fsys, voicePackManifest)
+	if err != nil {
+		return nil, err
+	}
+	pack := &VoicePack{}
+	if err = json.Unmarshal(manifestBytes, pack); err != nil {
+		return nil, err
+	}
+	for _, clip := range pack.allClips() {
+		if _, err := fs.Stat(fsys, clip); err != nil {
+			return nil, fmt.Errorf("missing clip %s", clip)
+		}
+	}
+	for name := range pack.Phases {
+		if _, ok := turnByName(name); !ok {
+			return nil, fmt.Errorf("unknown phase %s", name)
+		}
+	}
+	return pack, nil
+}
+
+func (p *VoicePack) allClips() []string {
+	clips := append([]string{}, p.Intro...)
+	for _, phase := range p.Phases {
+		clips = append(clips, phase.Enter...)
+		clips = append(clips, phase.Leave...)
+	}
+	return clips
+}
+
+// extractVoicePack copies the clips of an embedded pack to the cache
+// directory of the user, so that external players can open them. Every start
+// extracts the clips to the same directory again.
+func extractVoicePack(fsys fs.FS, pack *VoicePack) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	dir := filepath.Join(cacheDir, "werewolf", "voices", pack.name)
+	for _, clip := range pack.allClips() {
+		clipBytes, err := fs.ReadFile(fsys, clip)
+		if err != nil {
+			return "", err
+		}
+		name := filepath.Join(dir, filepath.FromSlash(clip))
+		if err = os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return "", err
+		}
+		if err = ioutil.WriteFile(name, clipBytes, 0644); err != nil {
+			return "", err
+		}
+	}
+	return dir, nil
+}
+
+// getVoicePack returns the pack with the given name, falling back to the
+// default pack. Without any pack loaded, the narration is silent.
+func getVoicePack(name string) *VoicePack {
+	if pack, ok := voicePacks[name]; ok {
+		return pack
+	}
+	if pack, ok := voicePacks[DefaultVoicePack]; ok {
+		return pack
+	}
+	return &VoicePack{name: DefaultVoicePack}
+}
+
+// clips returns the clips played on the cue of the phase.
+func (p *VoicePack) clips(turn int, cue string) []string {
+	phase, ok := p.Phases[turnName[turn]]
+	if !ok {
+		return nil
+	}
+	if cue == CueLeave {
+		return phase.Leave
+	}
+	return phase.Enter
+}
+
+func (p *VoicePack) play(player AudioPlayer, clips []string) {
+	for _, clip := range clips {
+		playAudio(player, filepath.Join(p.dir, filepath.FromSlash(clip)))
+	}
+}
+
+// Narrate plays the clips of the pack for the cue of the phase after a pause,
+// or speaks the text with DefaultSpeaker if the pack has no clip for it.
+func Narrate(pack string, turn int, cue string, text string) {
+	speakOrPlay(DefaultAudioPlayer, DefaultSpeaker, getVoicePack(pack), turn, cue, text)
+}
+
+func narrate(player AudioPlayer, pack *VoicePack, turn int, cue string) {
+	clips := pack.clips(turn, cue)
+	if len(clips) == 0 {
+		return
+	}
+	time.Sleep(SleepInterval)
+	pack.play(player, clips)
+}
+
+// PlayIntro plays the intro of the pack.
+func PlayIntro(pack string) {
+	p := getVoicePack(pack)
+	p.play(DefaultAudioPlayer, p.Intro)
+}
@@ -1,85 +1,35 @@
 package game
 
 import (
-	"sync"
 	"sync/atomic"
 )
 
 type Werewolf struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
+	player
 }
 
 func CreateWerewolf(id int, c *Controller) *Werewolf {
-	return &Werewolf{
-		id:         id,
-		roleName:   "Werewolf",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
-}
-
-func (v *Werewolf) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.WerewolfCount--
-}
-
-func (v *Werewolf) IsDead() bool {
-	return v.dead
-}
-
-func (v *Werewolf) GetRoleName() string {
-	return v.roleName
-}
-
-func (v *Werewolf) GetPlayerName() string {
-	return v.playerName
-}
-
-func (v *Werewolf) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
-	}
-	v.playerName = name
-	v.registered = true
-	return true
+	return &Werewolf{player: newPlayer(id, "Werewolf", c)}
 }
 
-func (v *Werewolf) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+func init() {
+	registerRole(&roleSpec{
+		name:       "Werewolf",
+		faction:    FactionWerewolf,
+		nightTurns: []int{TurnWerewolf},
+		abilities:  []int{SkillKill, SkillDontUse},
+		min:        1,
+		create:     func(id int, c *Controller) Role { return CreateWerewolf(id, c) },
+	})
 }
 
 func (v *Werewolf) GetActionCode() (bool, []int) {
-	if atomic.LoadInt32(v.controller.phase) != TurnWerewolf {
+	if atomic.LoadInt32(v.controller.phase) != TurnWerewolf || v.dead {
 		return false, nil
 	}
-	return true, []int{SkillKill}
+	return true, []int{SkillKill, SkillDontUse}
 }
 
 func (v *Werewolf) Act(action int, targetId int) (bool, string) {
-	if atomic.LoadInt32(v.controller.phase) != TurnWerewolf {
-		return false, "Not your turn!"
-	}
-	if action != SkillKill {
-		return false, "You're not able to use this skill!"
-	}
-
-	// kill somebody
-	target := v.controller.Roles[targetId]
-	if target.IsDead() {
-		return false, "Target is already dead!"
-	}
-	v.controller.waitChan[TurnWerewolf] <- targetId
-	return true, "Kill Succeeded!"
+	return v.controller.wolfAct(v.id, action, targetId)
 }
@@ -1,85 +1,60 @@
 package game
 
 import (
-	"sync"
 	"sync/atomic"
 )
 
 type WhiteWolf struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
+	player
 }
 
 func CreateWhiteWolf(id int, c *Controller) *WhiteWolf {
-	return &WhiteWolf{
-		id:         id,
-		roleName:   "WhiteWolf",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
-}
-
-func (v *WhiteWolf) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.WhiteWolfCount--
-}
-
-func (v *WhiteWolf) IsDead() bool {
-	return v.dead
-}
-
-func (v *WhiteWolf) GetRoleName() string {
-	return v.roleName
+	return &WhiteWolf{player: newPlayer(id, "WhiteWolf", c)}
 }
 
-func (v *WhiteWolf) GetPlayerName() string {
-	return v.playerName
+func init() {
+	registerRole(&roleSpec{
+		name:       "WhiteWolf",
+		faction:    FactionWerewolf,
+		nightTurns: []int{TurnWerewolf},
+		abilities:  []int{SkillKill, SkillDontUse, SkillExplode},
+		create:     func(id int, c *Controller) Role { return CreateWhiteWolf(id, c) },
+	})
 }
 
-func (v *WhiteWolf) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
+func (v *WhiteWolf) GetActionCode() (bool, []int) {
+	switch atomic.LoadInt32(v.controller.phase) {
+	case TurnWerewolf:
+		if !v.dead {
+			return true, []int{SkillKill, SkillDontUse}
+		}
+	case TurnDay:
+		if !v.dead {
+			return true, []int{SkillExplode}
+		}
 	}
-	v.playerName = name
-	v.registered = true
-	return true
-}
-
-func (v *WhiteWolf) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+	return false, nil
 }
 
-func (v *WhiteWolf) GetActionCode() (bool, []int) {
-	if atomic.LoadInt32(v.controller.phase) != TurnWerewolf {
-		return false, nil
+func (v *WhiteWolf) Act(action int, targetId int) (bool, string) {
+	if action == SkillExplode {
+		return v.explode(targetId)
 	}
-	return true, []int{SkillKill}
+	return v.controller.wolfAct(v.id, action, targetId)
 }
 
-func (v *WhiteWolf) Act(action int, targetId int) (bool, string) {
-	if atomic.LoadInt32(v.controller.phase) != TurnWerewolf {
-		return false, "Not your turn!"
+// explode reveals the White Wolf during the day, taking the target down and ending the day.
+func (v *WhiteWolf) explode(targetId int) (bool, string) {
+	if !v.controller.machine.awaits(TurnDay) || v.dead {
+		return false, "You can only explode during the day!"
 	}
-	if action != SkillKill {
-		return false, "You're not able to use this skill!"
+	if targetId == v.id {
+		return false, "You can't take yourself!"
 	}
-
-	// kill somebody
-	target := v.controller.Roles[targetId]
-	if target.IsDead() {
+	if v.controller.Roles[targetId].IsDead() {
 		return false, "Target is already dead!"
 	}
-	v.controller.waitChan[TurnWerewolf] <- targetId
-	return true, "Kill Succeeded!"
+	v.controller.explosion = &explosion{wolfId: v.id, targetId: targetId}
+	v.controller.input(TurnDay, explodeInput)
+	return true, "Explode Succeeded!"
 }
@@ -1,113 +1,135 @@
 package game
 
 import (
-	"sync"
 	"sync/atomic"
 )
 
-type Wizard struct {
-	id         int
-	dead       bool
-	playerName string
-	roleName   string
-	mutex      *sync.Mutex
-	registered bool
-	controller *Controller
-	isPoisoned bool
-	saveUsed   bool
-	poisonUsed bool
-}
-
-func CreateWizard(id int, c *Controller) *Wizard {
-	return &Wizard{
-		id:         id,
-		roleName:   "Wizard",
-		controller: c,
-		mutex:      &sync.Mutex{},
-	}
-}
-
-func (v *Wizard) Die(isPoisoned bool) {
-	v.dead = true
-	v.isPoisoned = isPoisoned
-	v.controller.WizardCount--
-}
+const (
+	SelfSaveNever      = "never"
+	SelfSaveFirstNight = "firstNight"
+	SelfSaveAlways     = "always"
+)
 
-func (v *Wizard) IsDead() bool {
-	return v.dead
-}
+var selfSaveRules = []string{SelfSaveNever, SelfSaveFirstNight, SelfSaveAlways}
 
-func (v *Wizard) GetRoleName() string {
-	return v.roleName
+// WizardRules are the house rules of the wizard (witch).
+type WizardRules struct {
+	// SelfSave tells whether the wizard may save herself: never, firstNight
+	// (the default) or always.
+	SelfSave string `json:"selfSave,omitempty"`
+	// BothPotions lets the wizard save and poison on the same night.
+	BothPotions bool `json:"bothPotions,omitempty"`
+	// ShowVictimAfterSave keeps telling the wizard who is killed once her save
+	// potion is used.
+	ShowVictimAfterSave bool `json:"showVictimAfterSave,omitempty"`
 }
 
-func (v *Wizard) GetPlayerName() string {
-	return v.playerName
+type Wizard struct {
+	player
+	saveUsed   bool
+	poisonUsed bool
 }
 
-func (v *Wizard) Register(name string) bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	if v.registered {
-		return false
-	}
-	v.playerName = name
-	v.registered = true
-	return true
+func CreateWizard(id int, c *Controller) *Wizard {
+	return &Wizard{player: newPlayer(id, "Wizard", c)}
 }
 
-func (v *Wizard) IsRegistered() bool {
-	v.mutex.Lock()
-	defer v.mutex.Unlock()
-	return v.registered
+func init() {
+	registerRole(&roleSpec{
+		name:       "Wizard",
+		faction:    FactionGod,
+		nightTurns: []int{TurnWizard},
+		abilities:  []int{SkillSave, SkillPoison, SkillDontUse},
+		max:        1,
+		create:     func(id int, c *Controller) Role { return CreateWizard(id, c) },
+	})
 }
 
 func (v *Wizard) GetActionCode() (bool, []int) {
-	if atomic.LoadInt32(v.controller.phase) != TurnWizard {
+	if atomic.LoadInt32(v.controller.phase) != TurnWizard || v.dead {
 		return false, nil
 	}
 	ret := []int{}
-	if !v.saveUsed {
+	if ok, _ := v.canSave(); ok {
 		ret = append(ret, SkillSave)
 	}
 	if !v.poisonUsed {
 		ret = append(ret, SkillPoison)
 	}
-	if len(ret) == 0 {
-		return false, nil
-	}
+	// with no potion left the wizard is still woken up, and passes
 	ret = append(ret, SkillDontUse)
 	return true, ret
 }
 
+// canSave reports whether the wizard may save the player killed tonight, or why not.
+func (v *Wizard) canSave() (bool, string) {
+	killedId := v.controller.killedTonight
+	switch {
+	case v.saveUsed:
+		return false, "Your save potion is already Used!"
+	case v.controller.savedTonight:
+		return false, "You already saved tonight!"
+	case killedId < 0:
+		return false, "Nobody is killed tonight!"
+	case killedId == v.id && !v.canSaveSelf():
+		return false, "You can't save yourself!"
+	}
+	return true, ""
+}
+
+func (v *Wizard) canSaveSelf() bool {
+	switch v.controller.wizardRules.SelfSave {
+	case SelfSaveAlways:
+		return true
+	case SelfSaveFirstNight:
+		return v.controller.machine.state().Day == 1
+	}
+	return false
+}
+
+// knowsVictim reports whether the wizard is told who is killed tonight: only
+// while she has the save potion, unless the rules say otherwise.
+func (v *Wizard) knowsVictim() bool {
+	return !v.saveUsed || v.controller.wizardRules.ShowVictimAfterSave
+}
+
 func (v *Wizard) Act(action int, targetId int) (bool, string) {
-	if atomic.LoadInt32(v.controller.phase) != TurnWizard {
+	if !v.controller.machine.awaits(TurnWizard) || v.dead {
 		return false, "Not your turn!"
 	}
 
 	switch action {
 	case SkillSave:
-		if v.saveUsed {
-			return false, "Your save potion is already Used!"
+		if ok, reason := v.canSave(); !ok {
+			return false, reason
 		}
 		v.saveUsed = true
-		v.controller.waitChan[TurnWizard] <- -1
+		v.controller.savedTonight = true
 	case SkillPoison:
 		if v.poisonUsed {
 			return false, "Your poison is already Used!"
 		}
-		v.poisonUsed = true
 		target := v.controller.Roles[targetId]
 		if target.IsDead() {
 			return false, "Target is already dead!"
 		}
-		v.controller.waitChan[TurnWizard] <- targetId
-
+		v.poisonUsed = true
+		v.controller.poisonedTonight = targetId
 	case SkillDontUse:
-		v.controller.waitChan[TurnWizard] <- -2
+		v.controller.input(TurnWizard, -2)
 		return true, "Didn't use any skill!"
 	default:
 		return false, "You're not able to use this skill!"
 	}
+
+	if action == SkillPoison {
+		v.controller.input(TurnWizard, targetId)
+		return true, "Successfully use skill!"
+	}
+	// with both potions allowed, the wizard may still poison after saving
+	if v.controller.wizardRules.BothPotions && !v.poisonUsed {
+		return true, "Successfully use skill! You may still use your poison."
+	}
+	v.controller.input(TurnWizard, -1)
 	return true, "Successfully use skill!"
 }
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWizardSelfSave(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		day  int
+		ok   bool
+	}{
+		{"first night", SelfSaveFirstNight, 1, true},
+		{"second night", SelfSaveFirstNight, 2, false},
+		{"never", SelfSaveNever, 1, false},
+		{"always", SelfSaveAlways, 2, true},
+	}
+	for _, test := range tests {
+		c := dealtGame(&InitGameRequest{
+			Roles:       map[string]int{"Villager": 2, "Werewolf": 2, "Wizard": 1},
+			WizardRules: WizardRules{SelfSave: test.rule},
+		})
+		wizard := c.Roles[seatOf(c, "Wizard")].(*Wizard)
+		c.machine.day = test.day
+		c.killedTonight = wizard.id
+		if ok, reason := wizard.canSave(); ok != test.ok {
+			t.Errorf("%s: %v (%s), want %v", test.name, ok, reason, test.ok)
+		}
+	}
+}
+
+// TestWizardPotions checks that the wizard may use a single potion a night
+// unless both are allowed.
+func TestWizardPotions(t *testing.T) {
+	for _, both := range []bool{false, true} {
+		c := startTestGame(t, &InitGameRequest{
+			Roles:       map[string]int{"Villager": 3, "Werewolf": 2, "Wizard": 1},
+			WizardRules: WizardRules{BothPotions: both},
+		})
+		wolves, villagers, wizard := seatsOf(c, "Werewolf"), seatsOf(c, "Villager"), seatsOf(c, "Wizard")[0]
+		for _, id := range wolves {
+			play(t, c, TurnWerewolf, id, SkillKill, villagers[0])
+		}
+		play(t, c, TurnWizard, wizard, SkillSave, villagers[0])
+		if both {
+			play(t, c, TurnWizard, wizard, SkillPoison, wolves[0])
+		}
+		awaitPhase(t, c, TurnDay)
+		if dead, _ := c.query(func() interface{} { return c.Roles[wolves[0]].IsDead() }).(bool); dead != both {
+			t.Errorf("Both potions %v: the poisoned wolf dead %v, want %v", both, dead, both)
+		}
+	}
+}
+
+// TestWizardVictimMessage checks that the wizard is told the victim of the
+// night only during her turn.
+func TestWizardVictimMessage(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{Roles: map[string]int{"Villager": 3, "Werewolf": 2, "Wizard": 1}})
+	wolves, villagers, wizard := seatsOf(c, "Werewolf"), seatsOf(c, "Villager"), seatsOf(c, "Wizard")[0]
+	for _, id := range wolves {
+		play(t, c, TurnWerewolf, id, SkillKill, villagers[0])
+	}
+	awaitPhase(t, c, TurnWizard)
+	if res := c.HandleAction(wizard, GetAction, 0); !strings.Contains(res.Message, "killed tonight") {
+		t.Errorf("Got %q at night, want the victim", res.Message)
+	}
+	play(t, c, TurnWizard, wizard, SkillDontUse, 0)
+	awaitPhase(t, c, TurnDay)
+	if res := c.HandleAction(wizard, GetAction, 0); strings.Contains(res.Message, "killed tonight") {
+		t.Errorf("Got %q during the day, want no victim", res.Message)
+	}
+}
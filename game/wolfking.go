@@ -0,0 +1,52 @@
+package game
+
+import (
+	"sync/atomic"
+)
+
+// WolfKing is a werewolf taking a player down when banished or shot, like
+// the hunter, but not when poisoned or killed at night.
+type WolfKing struct {
+	player
+}
+
+func CreateWolfKing(id int, c *Controller) *WolfKing {
+	return &WolfKing{player: newPlayer(id, "WolfKing", c)}
+}
+
+func init() {
+	registerRole(&roleSpec{
+		name:       "WolfKing",
+		faction:    FactionWerewolf,
+		nightTurns: []int{TurnWerewolf},
+		abilities:  []int{SkillKill, SkillDontUse, SkillFire},
+		max:        1,
+		create:     func(id int, c *Controller) Role { return CreateWolfKing(id, c) },
+	})
+}
+
+// canShoot reports whether the Wolf King may shoot: not when poisoned.
+func (v *WolfKing) canShoot() bool {
+	return !v.isPoisoned
+}
+
+func (v *WolfKing) GetActionCode() (bool, []int) {
+	switch atomic.LoadInt32(v.controller.phase) {
+	case TurnWerewolf:
+		if !v.dead {
+			return true, []int{SkillKill, SkillDontUse}
+		}
+	case TurnHunterShot:
+		if v.controller.shooting == v.id {
+			return true, []int{SkillFire, SkillDontUse}
+		}
+	}
+	return false, nil
+}
+
+func (v *WolfKing) Act(action int, targetId int) (bool, string) {
+	if v.controller.machine.current() == TurnHunterShot {
+		return v.controller.shotAct(v.id, action, targetId)
+	}
+	return v.controller.wolfAct(v.id, action, targetId)
+}
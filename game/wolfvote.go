@@ -0,0 +1,147 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// MajorityVote kills the target of more than half of the living werewolves.
+	MajorityVote = "majority"
+	// UnanimousVote kills the target once all living werewolves picked it.
+	UnanimousVote = "unanimous"
+	// LastChangeVote kills the latest pick when every werewolf voted or time is up.
+	LastChangeVote = "lastChange"
+)
+
+// wolfVoteRules are the rules of the werewolf vote, the first one is the default.
+var wolfVoteRules = []string{MajorityVote, UnanimousVote, LastChangeVote}
+
+// noKill is the vote of a werewolf who wants nobody killed tonight.
+const noKill = -1
+
+// TeamVote is the current pick of a werewolf, -1 for no kill.
+type TeamVote struct {
+	Id     int `json:"id"`
+	Target int `json:"target"`
+}
+
+// voteWolf records the pick of a werewolf and ends the turn once the pack agrees.
+func (c *Controller) voteWolf(id int, target int) string {
+	c.wolfVotes[id] = target
+	c.lastWolfVote = target
+	if killedId, ok := c.wolfVoteResult(false); ok {
+		c.input(TurnWerewolf, killedId)
+		if killedId == noKill {
+			return "The pack agreed to kill nobody tonight."
+		}
+		return fmt.Sprintf("The pack agreed to kill player %d.", killedId+1)
+	}
+	c.machine.wait(turnName[TurnWerewolf], c.pendingWolves())
+	return "Vote recorded. " + c.teamVotesMessage()
+}
+
+// pendingWolves returns the living werewolves who haven't voted yet, or the
+// whole pack once everybody voted without agreeing, as they may change their votes.
+func (c *Controller) pendingWolves() []int {
+	wolves := c.playersInTurn(TurnWerewolf)
+	ids := []int{}
+	for _, id := range wolves {
+		if _, ok := c.wolfVotes[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return wolves
+	}
+	return ids
+}
+
+// wolfVoteResult returns the player killed by the votes of the pack, if the
+// vote is decided. When final, e.g. at the deadline, the pick is made from the
+// votes cast so far.
+func (c *Controller) wolfVoteResult(final bool) (int, bool) {
+	wolves := c.playersInTurn(TurnWerewolf)
+	counts := map[int]int{}
+	voted := 0
+	for _, id := range wolves {
+		if target, ok := c.wolfVotes[id]; ok {
+			counts[target]++
+			voted++
+		}
+	}
+	if voted == 0 {
+		return 0, false
+	}
+	all := voted == len(wolves)
+	switch c.wolfVoteRule {
+	case UnanimousVote:
+		if len(counts) == 1 && (all || final) {
+			for target := range counts {
+				return target, true
+			}
+		}
+	case LastChangeVote:
+		if all || final {
+			return c.lastWolfVote, true
+		}
+	default:
+		best, bestCount, tied := 0, 0, false
+		for target, count := range counts {
+			if count > bestCount {
+				best, bestCount, tied = target, count, false
+			} else if count == bestCount {
+				tied = true
+			}
+		}
+		if bestCount*2 > len(wolves) || (final && !tied) {
+			return best, true
+		}
+	}
+	return 0, false
+}
+
+// teamVotes returns the picks of the living werewolves, by id.
+func (c *Controller) teamVotes() []TeamVote {
+	votes := []TeamVote{}
+	for _, id := range c.playersInTurn(TurnWerewolf) {
+		if target, ok := c.wolfVotes[id]; ok {
+			votes = append(votes, TeamVote{Id: id, Target: target})
+		}
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i].Id < votes[j].Id })
+	return votes
+}
+
+func (c *Controller) teamVotesMessage() string {
+	picks := []string{}
+	for _, v := range c.teamVotes() {
+		if v.Target == noKill {
+			picks = append(picks, fmt.Sprintf("player %d: no kill", v.Id+1))
+		} else {
+			picks = append(picks, fmt.Sprintf("player %d: kill %d", v.Id+1, v.Target+1))
+		}
+	}
+	if len(picks) == 0 {
+		return "No vote yet."
+	}
+	return "Votes: " + strings.Join(picks, ", ") + "."
+}
+
+// wolfAct is the night action of a werewolf: voting for a target, or for no kill.
+func (c *Controller) wolfAct(id int, action int, targetId int) (bool, string) {
+	if !c.machine.awaits(TurnWerewolf) || c.Roles[id].IsDead() {
+		return false, "Not your turn!"
+	}
+	switch action {
+	case SkillDontUse:
+		return true, c.voteWolf(id, noKill)
+	case SkillKill:
+		if c.Roles[targetId].IsDead() {
+			return false, "Target is already dead!"
+		}
+		return true, c.voteWolf(id, targetId)
+	}
+	return false, "You're not able to use this skill!"
+}
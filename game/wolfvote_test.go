@@ -0,0 +1,67 @@
+package game
+
+import "testing"
+
+func TestWolfVoteRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule string
+		// votes are the picks of the wolves, in seat order, last one last.
+		votes   []int
+		final   bool
+		decided bool
+		killed  int
+	}{
+		{"majority", MajorityVote, []int{0, 0}, false, true, 0},
+		{"no majority yet", MajorityVote, []int{0}, false, false, 0},
+		{"no majority at the deadline", MajorityVote, []int{0}, true, true, 0},
+		{"tie at the deadline", MajorityVote, []int{0, 1}, true, false, 0},
+		{"majority for no kill", MajorityVote, []int{noKill, noKill, 1}, false, true, noKill},
+		{"unanimous", UnanimousVote, []int{1, 1, 1}, false, true, 1},
+		{"unanimous so far", UnanimousVote, []int{1, 1}, false, false, 0},
+		{"unanimous so far at the deadline", UnanimousVote, []int{1, 1}, true, true, 1},
+		{"split", UnanimousVote, []int{1, 0, 1}, true, false, 0},
+		{"last change", LastChangeVote, []int{0, 1, 2}, false, true, 2},
+		{"last change, votes missing", LastChangeVote, []int{0, 1}, false, false, 0},
+		{"last change at the deadline", LastChangeVote, []int{0, 1}, true, true, 1},
+	}
+	for _, test := range tests {
+		c := dealtGame(&InitGameRequest{Roles: map[string]int{"Villager": 3, "Werewolf": 3}, WolfVoteRule: test.rule})
+		wolves, villagers := c.playersInTurn(TurnWerewolf), []int{}
+		for i, r := range c.Roles {
+			if r.GetRoleName() == "Villager" {
+				villagers = append(villagers, i)
+			}
+		}
+		c.wolfVotes = map[int]int{}
+		for i, vote := range test.votes {
+			target := noKill
+			if vote >= 0 {
+				target = villagers[vote]
+			}
+			c.wolfVotes[wolves[i]] = target
+			c.lastWolfVote = target
+		}
+		want := noKill
+		if test.killed >= 0 {
+			want = villagers[test.killed]
+		}
+		killed, decided := c.wolfVoteResult(test.final)
+		switch {
+		case decided != test.decided:
+			t.Errorf("%s: decided %v, want %v", test.name, decided, test.decided)
+		case decided && killed != want:
+			t.Errorf("%s: player %d killed, want player %d", test.name, killed+1, want+1)
+		}
+	}
+}
+
+// TestPendingWolves checks that the werewolves who voted are no longer waited on.
+func TestPendingWolves(t *testing.T) {
+	c := startTestGame(t, &InitGameRequest{Roles: map[string]int{"Villager": 3, "Werewolf": 3}})
+	wolves, villagers := seatsOf(c, "Werewolf"), seatsOf(c, "Villager")
+	play(t, c, TurnWerewolf, wolves[0], SkillKill, villagers[0])
+	if waiting := c.GetState().WaitingOn; len(waiting) != 2 || isInSlice(wolves[0], waiting) {
+		t.Errorf("Waiting on %v, want %v", waiting, wolves[1:])
+	}
+}
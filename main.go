@@ -1,14 +1,36 @@
 package main
 
 import (
+	"bufio"
+	"embed"
+	"encoding/json"
+	"flag"
+	"fmt"
 	"github.com/haomingzhang/werewolf/client"
 	"github.com/haomingzhang/werewolf/game"
+	"io/fs"
+	"io/ioutil"
 	"log"
 	"os"
+	"runtime"
 )
 
+// defaultVoicePack is the narrator built into the binary.
+//
+//go:embed audio
+var defaultVoicePack embed.FS
+
 func main() {
-	args := os.Args[1:]
+	audio := flag.String("audio", defaultAudioCommand(), "command playing the audio clips, e.g. mpg123, ffplay or aplay, or none for no sound")
+	tts := flag.String("tts", "none", "text-to-speech command reading the narration which has no clip, e.g. espeak or say")
+	voices := flag.String("voices", game.VoicePacksDir, "directory of the voice packs, one directory per pack")
+	flag.Parse()
+	game.DefaultAudioPlayer = game.ParseAudioPlayer(*audio)
+	game.DefaultSpeaker = game.ParseSpeaker(*tts)
+	game.VoicePacksDir = *voices
+	loadVoicePacks()
+
+	args := flag.Args()
 	if len(args) == 0 {
 		runLocal()
 	}
@@ -18,7 +40,20 @@ func main() {
 		runServer()
 		return
 	case game.ClientMode:
-		runClient(args[1])
+		requireArgs(args, "client <server host> [room]")
+		room := game.DefaultRoom
+		if len(args) > 2 {
+			room = args[2]
+		}
+		runClient(args[1], room)
+		return
+	case game.ReplayMode:
+		requireArgs(args, "replay <game log>")
+		runReplay(args[1])
+		return
+	case game.VerifyMode:
+		requireArgs(args, "verify <game log>")
+		runVerify(args[1])
 		return
 	case game.LocalMode:
 		fallthrough
@@ -27,22 +62,35 @@ func main() {
 	}
 }
 
+// requireArgs prints the usage of the command and exits if its argument is missing.
+func requireArgs(args []string, usage string) {
+	if len(args) < 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s %s\n", os.Args[0], usage)
+		os.Exit(2)
+	}
+}
+
+func defaultAudioCommand() string {
+	if runtime.GOOS == "darwin" {
+		return "afplay"
+	}
+	return "mpg123"
+}
+
 func runLocal() {
 	playBeginGame()
-	gs := &game.GameServer{}
-	gs.Controller = game.CreateController(game.LocalMode)
+	gs := &game.GameServer{Mode: game.LocalMode}
 	gs.Start()
 }
 
 func runServer() {
-	gs := &game.GameServer{}
-	gs.Controller = game.CreateController(game.ServerMode)
+	gs := &game.GameServer{Mode: game.ServerMode}
 	gs.Start()
 }
 
-func runClient(serverHost string) {
+func runClient(serverHost string, room string) {
 	playBeginGame()
-	c, err := client.CreateWerewolfClient(serverHost)
+	c, err := client.CreateWerewolfClient(serverHost, room)
 	if err != nil {
 		log.Fatal(err)
 		return
@@ -50,6 +98,52 @@ func runClient(serverHost string) {
 	c.Start()
 }
 
+func runReplay(fileName string) {
+	events, err := game.ReadEventLog(fileName)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	stdin := bufio.NewReader(os.Stdin)
+	game.Replay(game.ReplayMode, events, func(e *game.Event, c *game.Controller) {
+		eventBytes, _ := json.Marshal(e)
+		stateBytes, _ := json.Marshal(c.GetState())
+		fmt.Printf("%s\n  => %s\n", eventBytes, stateBytes)
+		stdin.ReadString('\n')
+	})
+	fmt.Println("Replay finished.")
+}
+
+// runVerify checks a saved /postgame response against its commitment.
+func runVerify(fileName string) {
+	resBytes, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	res := &game.PostGameResponse{}
+	err = json.Unmarshal(resBytes, res)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
+	if !res.Verify() {
+		fmt.Println("The deal does NOT match the commitment!")
+		os.Exit(1)
+	}
+	fmt.Println("The deal matches the commitment.")
+}
+
+func loadVoicePacks() {
+	pack, err := fs.Sub(defaultVoicePack, "audio")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = game.LoadVoicePacks(pack, game.VoicePacksDir); err != nil {
+		log.Fatal(err)
+	}
+}
+
 func playBeginGame() {
-	game.PlayAudio("serverBegin.mpg")
+	game.PlayIntro(game.DefaultVoicePack)
 }